@@ -18,6 +18,26 @@ type Algorithm interface {
 	Stats() Stats
 }
 
+// A PinnedAlgorithm is an Algorithm which can keep some points of its point groups in place.
+type PinnedAlgorithm interface {
+	Algorithm
+
+	// SetPins sets the points which are pinned. The mutation method is wrapped so pinned points are never mutated.
+	SetPins(pins normgeom.Pins)
+
+	// Pins returns the points which are pinned.
+	Pins() normgeom.Pins
+}
+
+// A RecalculatingAlgorithm is an Algorithm whose fitnesses can be recalculated in between generations,
+// which is needed if its fitness functions change (such as when a palette is refined).
+type RecalculatingAlgorithm interface {
+	Algorithm
+
+	// Recalculate recalculates the fitnesses of the point groups which the next generation is based on.
+	Recalculate()
+}
+
 // Stats contains the basic statistics of an Algorithm.
 type Stats struct {
 	BestFitness float64
@@ -1,14 +1,18 @@
 package algorithm
 
 import (
+	"fmt"
 	"github.com/RH12503/Triangula/algorithm/evaluator"
+	triColor "github.com/RH12503/Triangula/color"
 	"github.com/RH12503/Triangula/fitness"
 	"github.com/RH12503/Triangula/generator"
 	imageData "github.com/RH12503/Triangula/image"
 	"github.com/RH12503/Triangula/mutation"
 	"github.com/RH12503/Triangula/normgeom"
 	"github.com/RH12503/Triangula/random"
+	"github.com/stretchr/testify/assert"
 	"image"
+	"image/color"
 	_ "image/jpeg"
 	"log"
 	"math/rand"
@@ -46,7 +50,7 @@ func BenchmarkAlgorithm(b *testing.B) {
 		return (generator.RandomGenerator{}).Generate(1000)
 	}
 	evaluatorFactory := func(n int) evaluator.Evaluator {
-		return evaluator.NewParallel(fitness.TrianglesImageFunctions(imgData, 5, n), 22)
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(imgData, n), evaluator.DefaultCacheSize)
 	}
 
 	mutator := mutation.NewGaussianMethod(2/1000, 0.3)
@@ -60,3 +64,161 @@ func BenchmarkAlgorithm(b *testing.B) {
 	}
 	real()
 }
+
+// testImage returns a generated image with gradients and hard edges.
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			c := color.RGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 128, A: 255}
+			if (x-w/2)*(x-w/2)+(y-h/2)*(y-h/2) < w*h/16 {
+				c.B = 255 - c.R
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// BenchmarkCacheSize measures the time per generation with different cache sizes.
+func BenchmarkCacheSize(b *testing.B) {
+	imgData := imageData.ToData(testImage(300, 300))
+
+	for _, size := range []int{64 << 10, 1 << 20, 16 << 20, 128 << 20} {
+		b.Run(fmt.Sprintf("%vKiB", size>>10), func(b *testing.B) {
+			random.Seed(0)
+			rand.Seed(0)
+
+			pointFactory := func() normgeom.NormPointGroup {
+				return (generator.RandomGenerator{}).Generate(500)
+			}
+
+			var eval interface{ CacheStats() fitness.CacheStats }
+			evaluatorFactory := func(n int) evaluator.Evaluator {
+				e := evaluator.NewParallelSize(fitness.TrianglesImageFunctions(imgData, n), size)
+				eval = e
+				return e
+			}
+
+			algo := NewModifiedGenetic(pointFactory, 100, 5, evaluatorFactory, mutation.DefaultGaussianMethod(500))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				algo.Step()
+			}
+			b.StopTimer()
+
+			stats := eval.CacheStats()
+			b.ReportMetric(stats.HitRate(), "hit-rate")
+			b.ReportMetric(float64(stats.Evictions)/float64(b.N), "evictions/gen")
+		})
+	}
+}
+
+func TestPaletteOptimizer(t *testing.T) {
+	random.Seed(0)
+	rand.Seed(0)
+
+	imgData := imageData.ToData(testImage(60, 60))
+
+	palette := triColor.Palette{{R: 0.5, G: 0.5, B: 0.5}, {R: 0.4, G: 0.4, B: 0.4}}
+	before := append(triColor.Palette{}, palette...)
+
+	pointFactory := func() normgeom.NormPointGroup {
+		return (generator.RandomGenerator{}).Generate(30)
+	}
+	evaluatorFactory := func(n int) evaluator.Evaluator {
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(imgData, n, fitness.WithPalette(palette)), 1<<20)
+	}
+
+	algo := NewPaletteOptimizer(NewModifiedGenetic(pointFactory, 20, 2, evaluatorFactory, mutation.DefaultGaussianMethod(30)),
+		palette, imgData, TriangleShapes, 2)
+
+	for i := 0; i < 4; i++ {
+		algo.Step()
+	}
+
+	assert.NotEqual(t, palette, before)
+	assert.Equal(t, algo.Stats().Generation, 4)
+
+	// The palette was refined in the last generation, so the fitnesses were recalculated with the new palette
+	fit := fitness.TrianglesImageFunctions(imgData, 1, fitness.WithPalette(palette))[0]
+	assert.InDelta(t, algo.Stats().BestFitness, fit.Calculate(fitness.PointsData{Points: algo.Best()}), 1e-9)
+
+	assert.Panics(t, func() {
+		NewPaletteOptimizer(algo, palette, imgData, TriangleShapes, 0)
+	})
+}
+
+func TestModifiedGenetic_SetPins(t *testing.T) {
+	random.Seed(0)
+	rand.Seed(0)
+
+	imgData := imageData.ToData(testImage(60, 60))
+
+	gen := generator.NewPinnedGenerator(generator.RandomGenerator{}, normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 0.5, Y: 0.5}})
+	pointFactory := func() normgeom.NormPointGroup {
+		return gen.Generate(30)
+	}
+	evaluatorFactory := func(n int) evaluator.Evaluator {
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(imgData, n), 1<<20)
+	}
+
+	algo := NewModifiedGenetic(pointFactory, 20, 2, evaluatorFactory, mutation.NewGaussianMethod(0.5, 0.3))
+	algo.SetPins(gen.Pins())
+
+	for i := 0; i < 5; i++ {
+		algo.Step()
+	}
+
+	assert.Equal(t, algo.Best()[:2], normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 0.5, Y: 0.5}})
+	assert.Equal(t, algo.Pins().Indexes(), []int{0, 1})
+}
+
+func TestGreedy(t *testing.T) {
+	rand.Seed(0)
+
+	imgData := imageData.ToData(testImage(60, 60))
+
+	algo := NewGreedy(imgData, 50)
+	algo.Step()
+
+	assert.Equal(t, len(algo.Best()), 50)
+	assert.Equal(t, algo.Best()[:4], normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+
+	// The result is deterministic
+	other := NewGreedy(imgData, 50)
+	other.Step()
+	assert.Equal(t, other.Best(), algo.Best())
+
+	// And much better than random points
+	randomFitness := fitness.NewTrianglesImageFunction(imgData).Calculate(fitness.PointsData{
+		Points: (generator.RandomGenerator{}).Generate(50),
+	})
+	assert.True(t, algo.Stats().BestFitness > randomFitness)
+
+	// Further generations don't change the points
+	algo.Step()
+	assert.Equal(t, algo.Stats().Generation, 2)
+	assert.Equal(t, algo.Best(), other.Best())
+
+	// Points are never inserted twice, even on a noisy image with many points
+	for seed := int64(0); seed < 3; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+
+		noise := image.NewRGBA(image.Rect(0, 0, 64, 48))
+		for i := range noise.Pix {
+			noise.Pix[i] = uint8(rng.Intn(256))
+		}
+
+		algo := NewGreedy(imageData.ToData(noise), 400)
+		algo.Step()
+
+		points := map[normgeom.NormPoint]bool{}
+		for _, p := range algo.Best() {
+			assert.False(t, points[p])
+			points[p] = true
+		}
+		assert.Equal(t, len(points), 400)
+	}
+}
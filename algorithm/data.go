@@ -1,6 +1,9 @@
 package algorithm
 
-import "github.com/RH12503/Triangula/mutation"
+import (
+	"github.com/RH12503/Triangula/mutation"
+	"sort"
+)
 
 // FitnessData stores the fitness of a point group.
 type FitnessData struct {
@@ -24,3 +27,12 @@ func (m *MutationsData) Clear() {
 func (m MutationsData) Count() int {
 	return len(m.Mutations)
 }
+
+// sortBases sorts the first n members of a population, leaving the other members in place.
+func sortBases(population sort.Interface, n int) {
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && population.Less(j, j-1); j-- {
+			population.Swap(j, j-1)
+		}
+	}
+}
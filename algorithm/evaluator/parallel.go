@@ -4,14 +4,19 @@ import (
 	"github.com/RH12503/Triangula/fitness"
 )
 
+// DefaultCacheSize is the number of bytes used by a parallel evaluator's caches which works well for most images.
+const DefaultCacheSize = 256 << 20
+
 // parallel is a fitness evaluator that supports parallel calculations.
 // It stores and updates a cache, and contains a fitness.CacheFunction for each member
 // to calculate fitnesses.
 type parallel struct {
 	evaluators []fitness.CacheFunction
 
-	cache     []fitness.CacheData // The current cache being used by the fitness functions.
-	nextCache []fitness.CacheData // The cache for the next generation.
+	cache     *fitness.Cache // The current cache being used by the fitness functions.
+	nextCache *fitness.Cache // The cache for the next generation.
+
+	stats fitness.CacheStats // The hits and misses of all the fitness functions.
 }
 
 func (p parallel) Get(i int) fitness.Function {
@@ -27,9 +32,11 @@ func (p *parallel) Update(i int) {
 
 	// Put triangles that have been calculated from the fitness function into the cache
 	for _, d := range eval.Cache() {
-		p.cache[d.CachedHash()] = d
+		p.cache.Put(d)
 	}
 
+	p.stats.Add(eval.TakeStats())
+
 	eval.SetCache(p.cache)
 }
 
@@ -41,12 +48,25 @@ func (p *parallel) Swap(i, j int) {
 	p.evaluators[i], p.evaluators[j] = p.evaluators[j], p.evaluators[i]
 }
 
-// NewParallel creates a new parallel evaluator.
-func NewParallel(fitnessFuncs []fitness.CacheFunction, cachePowerOf2 int) *parallel {
+// CacheStats returns the total cache hits, misses and evictions of the evaluator.
+func (p parallel) CacheStats() fitness.CacheStats {
+	stats := p.stats
+	stats.Evictions = p.cache.Evictions() + p.nextCache.Evictions()
+	return stats
+}
 
+// NewParallelSize creates a new parallel evaluator whose caches use at most cacheSize bytes in total.
+func NewParallelSize(fitnessFuncs []fitness.CacheFunction, cacheSize int) *parallel {
 	return &parallel{
 		evaluators: fitnessFuncs,
-		cache:      make([]fitness.CacheData, 1<<cachePowerOf2),
-		nextCache:  make([]fitness.CacheData, 1<<cachePowerOf2),
+		cache:      fitness.NewCache(cacheSize / 2),
+		nextCache:  fitness.NewCache(cacheSize / 2),
 	}
 }
+
+// NewParallel creates a new parallel evaluator whose caches each store around 2^cachePowerOf2 triangles.
+//
+// Deprecated: Use NewParallelSize, which specifies the size of the caches in bytes.
+func NewParallel(fitnessFuncs []fitness.CacheFunction, cachePowerOf2 int) *parallel {
+	return NewParallelSize(fitnessFuncs, 2*(1<<cachePowerOf2)*fitness.CacheEntrySize)
+}
@@ -0,0 +1,193 @@
+package algorithm
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/fitness"
+	"github.com/RH12503/Triangula/geom"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/rasterize"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"time"
+)
+
+// greedy is a fast deterministic algorithm which builds a triangulation by repeatedly inserting a point at the
+// pixel with the largest error in the triangle with the largest error, until it has the number of points needed.
+// It reaches its final result in the first generation, and can be used instead of a genetic algorithm,
+// or to create a starting point group for one.
+type greedy struct {
+	target    image.Data
+	numPoints int
+
+	triangulation *incrdelaunay.Delaunay
+
+	// The error of each triangle, and the triangles of the previous insertion (for performance purposes).
+	triangles, oldTriangles map[[3]incrdelaunay.Point]greedyTriangle
+
+	best normgeom.NormPointGroup
+
+	stats Stats
+}
+
+// greedyTriangle stores the error of a triangle of the triangulation.
+type greedyTriangle struct {
+	error float64            // The sum of the squared differences between each pixel and the average color.
+	worst incrdelaunay.Point // The pixel furthest from the average color.
+	ok    bool               // If the triangle has a pixel which can be inserted.
+}
+
+func (g *greedy) Step() {
+	t := time.Now()
+
+	if len(g.best) < g.numPoints {
+		g.insertAll()
+		g.stats.BestFitness = fitness.NewTrianglesImageFunction(g.target).Calculate(fitness.PointsData{Points: g.best})
+	}
+
+	g.stats.Generation++
+	g.stats.TimeForGen = time.Since(t)
+}
+
+// insertAll inserts points until there are enough points, or every triangle is too small to be split.
+func (g *greedy) insertAll() {
+	w, h := g.target.Size()
+
+	for len(g.best) < g.numPoints {
+		g.updateTriangles()
+
+		// Find the triangle with the largest error, breaking ties by position so the result is deterministic
+		var worst greedyTriangle
+		for key, t := range g.triangles {
+			if t.ok && g.triangulation.HasPoint(t.worst) {
+				// The pixel was inserted without removing the triangle, so find another pixel
+				t = g.triangleError(incrdelaunay.NewTriangle(key[0], key[1], key[2]))
+				g.triangles[key] = t
+			}
+			if !t.ok {
+				continue
+			}
+			if !worst.ok || t.error > worst.error ||
+				(t.error == worst.error && (t.worst.Y < worst.worst.Y || (t.worst.Y == worst.worst.Y && t.worst.X < worst.worst.X))) {
+				worst = t
+			}
+		}
+
+		if !worst.ok {
+			return
+		}
+
+		g.triangulation.Insert(worst.worst)
+		g.best = append(g.best, normgeom.NormPoint{
+			X: float64(worst.worst.X) / float64(w),
+			Y: float64(worst.worst.Y) / float64(h),
+		})
+	}
+}
+
+// updateTriangles calculates the errors of the triangles which were created by the last insertion.
+func (g *greedy) updateTriangles() {
+	g.triangles, g.oldTriangles = g.oldTriangles, g.triangles
+
+	for k := range g.triangles {
+		delete(g.triangles, k)
+	}
+
+	g.triangulation.IterTriangles(func(t incrdelaunay.Triangle) {
+		key := [3]incrdelaunay.Point{t.A, t.B, t.C}
+
+		if data, ok := g.oldTriangles[key]; ok {
+			g.triangles[key] = data
+		} else {
+			g.triangles[key] = g.triangleError(t)
+		}
+	})
+}
+
+// triangleError calculates the error of a triangle and the pixel with the largest error.
+func (g *greedy) triangleError(t incrdelaunay.Triangle) greedyTriangle {
+	w, h := g.target.Size()
+
+	tri := geom.NewTriangle(int(t.A.X), int(t.A.Y), int(t.B.X), int(t.B.Y), int(t.C.X), int(t.C.Y))
+
+	var average color.AverageRGB
+	rasterize.DDATriangle(tri, func(x, y int) {
+		if x >= 0 && y >= 0 && x < w && y < h {
+			average.Add(g.target.RGBAt(x, y))
+		}
+	})
+
+	mean := average.Average()
+
+	var data greedyTriangle
+	maxDist := -1.
+
+	rasterize.DDATriangle(tri, func(x, y int) {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return
+		}
+
+		dist := color.DistSq(g.target.RGBAt(x, y), mean)
+		data.error += dist
+
+		// Only pixels inside the triangle which aren't already points can be inserted, as the pixels
+		// along the edges can be outside it
+		p := incrdelaunay.Point{X: int32(x), Y: int32(y)}
+		if dist > maxDist && inTriangle(t, p) && !t.HasVertex(p) && !g.triangulation.HasPoint(p) {
+			maxDist = dist
+			data.worst = p
+			data.ok = true
+		}
+	})
+
+	return data
+}
+
+// inTriangle returns if a point is inside a triangle or on its edges.
+func inTriangle(t incrdelaunay.Triangle, p incrdelaunay.Point) bool {
+	side := func(a, b incrdelaunay.Point) int64 {
+		return int64(b.X-a.X)*int64(p.Y-a.Y) - int64(b.Y-a.Y)*int64(p.X-a.X)
+	}
+
+	d0, d1, d2 := side(t.A, t.B), side(t.B, t.C), side(t.C, t.A)
+
+	negative := d0 < 0 || d1 < 0 || d2 < 0
+	positive := d0 > 0 || d1 > 0 || d2 > 0
+
+	return !(negative && positive)
+}
+
+func (g greedy) Best() normgeom.NormPointGroup {
+	return g.best
+}
+
+func (g greedy) Stats() Stats {
+	return g.stats
+}
+
+// NewGreedy returns an Algorithm which triangulates a target image with a number of points, starting from the
+// corners of the image and repeatedly inserting a point at the pixel with the largest error in the triangle
+// with the largest error. It's deterministic, and much faster than the genetic algorithms.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func NewGreedy(target image.Data, numPoints int) *greedy {
+	w, h := target.Size()
+
+	g := greedy{
+		target:        target,
+		numPoints:     numPoints,
+		triangulation: incrdelaunay.NewDelaunay(w, h),
+		triangles:     map[[3]incrdelaunay.Point]greedyTriangle{},
+		oldTriangles:  map[[3]incrdelaunay.Point]greedyTriangle{},
+	}
+
+	corners := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+
+	for i, p := range corners {
+		if i >= numPoints {
+			break
+		}
+		g.triangulation.Insert(incrdelaunay.Point{X: int32(p.X * float64(w)), Y: int32(p.Y * float64(h))})
+		g.best = append(g.best, p)
+	}
+
+	return &g
+}
@@ -33,6 +33,8 @@ type modifiedGenetic struct {
 
 	best normgeom.NormPointGroup // The member of the population with the highest fitness.
 
+	pins normgeom.Pins // The points which are never mutated.
+
 	cutoff int // The number of members that are guaranteed to survive to the next generation.
 
 	stats Stats // Simple statistics relating to the algorithm.
@@ -184,6 +186,11 @@ func (g *modifiedGenetic) combineMutations() {
 			g.evaluator.SetBase(i, base)
 
 			for _, m := range g.beneficialMutations[base].Mutations {
+				// Pinned points keep the position of the base
+				if g.pins.Pinned(m.Index) {
+					continue
+				}
+
 				g.population[i][m.Index].X = m.New.X
 				g.population[i][m.Index].Y = m.New.Y
 				g.mutations[i] = append(g.mutations[i], m)
@@ -214,6 +221,21 @@ func (g *modifiedGenetic) updateFitnesses() {
 	g.stats.BestFitness = g.fitnesses[0].Fitness
 }
 
+// Recalculate recalculates the fitnesses of the bases with the current fitness functions. Only the bases
+// are recalculated, as the other members are replaced in the next generation.
+func (g *modifiedGenetic) Recalculate() {
+	for i := 0; i < g.cutoff; i++ {
+		g.mutations[i] = g.mutations[i][:0]
+		g.fitnesses[i].Fitness = g.evaluator.Get(i).Calculate(fitness.PointsData{Points: g.population[i]})
+		g.evaluator.Update(i)
+	}
+
+	sortBases(g, g.cutoff)
+
+	g.best.Set(g.population[0])
+	g.stats.BestFitness = g.fitnesses[0].Fitness
+}
+
 // getBase returns the base of a member given the index of that member.
 func (g modifiedGenetic) getBase(index int) int {
 	return index % g.cutoff
@@ -227,6 +249,15 @@ func (g modifiedGenetic) Stats() Stats {
 	return g.stats
 }
 
+func (g *modifiedGenetic) SetPins(pins normgeom.Pins) {
+	g.pins = pins
+	g.mutator = mutation.NewPinnedMethod(g.mutator, pins)
+}
+
+func (g modifiedGenetic) Pins() normgeom.Pins {
+	return g.pins
+}
+
 // Functions for sorting.
 
 func (g modifiedGenetic) Len() int {
@@ -0,0 +1,96 @@
+package algorithm
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/geom"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/rasterize"
+	"github.com/RH12503/Triangula/triangulation"
+)
+
+// paletteOptimizer optimizes the colors of a palette jointly with the points of another Algorithm.
+// Every few generations the palette is refined using k-means, so its colors better match the average
+// colors of the shapes created from the best point group.
+type paletteOptimizer struct {
+	Algorithm
+
+	palette color.Palette // Should be the same palette used by the fitness functions.
+	target  image.Data    // The image the shapes' colors are calculated from.
+
+	shapes   func(points normgeom.NormPointGroup, w, h int) []geom.Polygon // Creates the shapes from a point group.
+	interval int                                                           // The number of generations between each refinement.
+
+	colors  []color.RGB // For performance purposes.
+	weights []float64   // For performance purposes.
+}
+
+func (p *paletteOptimizer) Step() {
+	p.Algorithm.Step()
+
+	// The fitness functions aren't running in between generations, so the palette can be safely modified
+	if p.Stats().Generation%p.interval == 0 {
+		p.refine()
+
+		// The fitnesses the next generation is compared against were calculated with the old palette
+		if r, ok := p.Algorithm.(RecalculatingAlgorithm); ok {
+			r.Recalculate()
+		}
+	}
+}
+
+// refine refines the palette based on the shapes of the best point group.
+func (p *paletteOptimizer) refine() {
+	w, h := p.target.Size()
+
+	p.colors = p.colors[:0]
+	p.weights = p.weights[:0]
+
+	for _, poly := range p.shapes(p.Best(), w, h) {
+		var average color.AverageRGB
+
+		rasterize.DDAPolygon(poly, func(x, y int) {
+			average.Add(p.target.RGBAt(x, y))
+		})
+
+		if average.Count() > 0 {
+			p.colors = append(p.colors, average.Average())
+			p.weights = append(p.weights, float64(average.Count()))
+		}
+	}
+
+	p.palette.Refine(p.colors, p.weights, 1)
+}
+
+// NewPaletteOptimizer returns an Algorithm which runs algo while optimizing the colors of a palette,
+// refining the palette every interval generations. If algo is a RecalculatingAlgorithm, its fitnesses are
+// recalculated after each refinement.
+// The palette should be the one passed to the fitness functions using fitness.WithPalette, and shapes
+// should create the shapes of a point group, such as TriangleShapes or polygonation.Polygonate.
+func NewPaletteOptimizer(algo Algorithm, palette color.Palette, target image.Data,
+	shapes func(points normgeom.NormPointGroup, w, h int) []geom.Polygon, interval int) *paletteOptimizer {
+
+	if interval <= 0 {
+		panic("the interval must be positive")
+	}
+
+	return &paletteOptimizer{
+		Algorithm: algo,
+		palette:   palette,
+		target:    target,
+		shapes:    shapes,
+		interval:  interval,
+	}
+}
+
+// TriangleShapes returns the triangles of the Delaunay triangulation of a point group as polygons.
+func TriangleShapes(points normgeom.NormPointGroup, w, h int) []geom.Polygon {
+	triangles := triangulation.Triangulate(points, w, h)
+	polygons := make([]geom.Polygon, len(triangles))
+
+	for i, t := range triangles {
+		polygons[i] = geom.Polygon{Points: []geom.Point{t.Points[0], t.Points[1], t.Points[2]}}
+	}
+
+	return polygons
+}
@@ -30,6 +30,8 @@ type simple struct {
 
 	best normgeom.NormPointGroup // The member of the population with the highest fitness.
 
+	pins normgeom.Pins // The points which are never mutated.
+
 	cutoff int // The number of members that survive to the next generation.
 
 	stats Stats // Simple statistics relating to the algorithm.
@@ -95,6 +97,21 @@ func (s *simple) updateFitnesses() {
 	s.stats.BestFitness = s.fitnesses[0].Fitness
 }
 
+// Recalculate recalculates the fitnesses of the bases with the current fitness functions. Only the bases
+// are recalculated, as the other members are replaced in the next generation.
+func (s *simple) Recalculate() {
+	for i := 0; i < s.cutoff; i++ {
+		s.mutations[i] = s.mutations[i][:0]
+		s.fitnesses[i].Fitness = s.evaluator.Get(i).Calculate(fitness.PointsData{Points: s.population[i]})
+		s.evaluator.Update(i)
+	}
+
+	sortBases(s, s.cutoff)
+
+	s.best.Set(s.population[0])
+	s.stats.BestFitness = s.fitnesses[0].Fitness
+}
+
 // newGeneration populates a generation with new members.
 func (s *simple) newGeneration() {
 	i := 0
@@ -128,6 +145,15 @@ func (s simple) Stats() Stats {
 	return s.stats
 }
 
+func (s *simple) SetPins(pins normgeom.Pins) {
+	s.pins = pins
+	s.mutator = mutation.NewPinnedMethod(s.mutator, pins)
+}
+
+func (s simple) Pins() normgeom.Pins {
+	return s.pins
+}
+
 func (s simple) Len() int {
 	return len(s.fitnesses)
 }
@@ -37,3 +37,27 @@ func (argb AverageRGB) Average() RGB {
 func (argb AverageRGB) Count() uint {
 	return argb.count
 }
+
+// Luminance returns the perceived brightness of a color between 0 and 1, using the Rec. 601 weights.
+func Luminance(rgb RGB) float64 {
+	return 0.299*rgb.R + 0.587*rgb.G + 0.114*rgb.B
+}
+
+// Gray returns a gray color with a luminance.
+func Gray(l float64) RGB {
+	return RGB{l, l, l}
+}
+
+// A ToneMap maps a luminance between 0 and 1 to a color, and is used to render grayscale images.
+type ToneMap func(l float64) RGB
+
+// Duotone returns a ToneMap which blends from a dark color (at a luminance of 0) to a light color (at 1).
+func Duotone(dark, light RGB) ToneMap {
+	return func(l float64) RGB {
+		return RGB{
+			dark.R + (light.R-dark.R)*l,
+			dark.G + (light.G-dark.G)*l,
+			dark.B + (light.B-dark.B)*l,
+		}
+	}
+}
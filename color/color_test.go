@@ -19,3 +19,26 @@ func TestAverageRGB_Average(t *testing.T) {
 		B: 0.5,
 	})
 }
+
+func TestPalette_Nearest(t *testing.T) {
+	palette := Palette{{0, 0, 0}, {1, 1, 1}, {1, 0, 0}}
+
+	assert.Equal(t, palette.Nearest(RGB{0.1, 0.2, 0.1}), 0)
+	assert.Equal(t, palette.Nearest(RGB{0.9, 0.8, 0.7}), 1)
+	assert.Equal(t, palette.Nearest(RGB{0.7, 0.1, 0.2}), 2)
+}
+
+func TestKMeansPalette(t *testing.T) {
+	colors := []RGB{{0, 0, 0}, {0.1, 0, 0}, {1, 1, 1}, {0.9, 1, 1}}
+	weights := []float64{1, 1, 1, 3}
+
+	palette := KMeansPalette(colors, weights, 2, 5)
+
+	assert.Equal(t, len(palette), 2)
+	assert.Contains(t, palette, RGB{0.05, 0, 0})
+	assert.Contains(t, palette, RGB{0.925, 1, 1})
+
+	assert.Nil(t, KMeansPalette(colors, weights, 0, 5))
+	assert.Nil(t, KMeansPalette(colors, weights, -1, 5))
+	assert.Nil(t, KMeansPalette(nil, nil, 2, 5))
+}
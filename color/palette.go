@@ -0,0 +1,131 @@
+package color
+
+import "math"
+
+// Palette represents a fixed set of colors.
+type Palette []RGB
+
+// DistSq returns the squared distance between two colors.
+func DistSq(a, b RGB) float64 {
+	dR := a.R - b.R
+	dG := a.G - b.G
+	dB := a.B - b.B
+
+	return dR*dR + dG*dG + dB*dB
+}
+
+// Nearest returns the index of the color in the palette closest to a color.
+func (p Palette) Nearest(rgb RGB) int {
+	nearest := 0
+	nearestDist := -1.
+
+	for i, c := range p {
+		if dist := DistSq(c, rgb); nearestDist == -1 || dist < nearestDist {
+			nearest = i
+			nearestDist = dist
+		}
+	}
+
+	return nearest
+}
+
+// Refine moves the colors of the palette towards the weighted average of the colors closest to them,
+// using a number of iterations of Lloyd's algorithm (k-means).
+// colors[i] has the weight weights[i]. Colors of the palette which no colors are closest to are left unchanged.
+func (p Palette) Refine(colors []RGB, weights []float64, iterations int) {
+	sums := make([]RGB, len(p))
+	totals := make([]float64, len(p))
+
+	for it := 0; it < iterations; it++ {
+		for i := range sums {
+			sums[i] = RGB{}
+			totals[i] = 0
+		}
+
+		// Add each color to the palette color it's closest to
+		for i, c := range colors {
+			n := p.Nearest(c)
+			w := weights[i]
+
+			sums[n].R += c.R * w
+			sums[n].G += c.G * w
+			sums[n].B += c.B * w
+			totals[n] += w
+		}
+
+		for i, s := range sums {
+			if totals[i] > 0 {
+				p[i] = NewRGB(s.R/totals[i], s.G/totals[i], s.B/totals[i])
+			}
+		}
+	}
+}
+
+// KMeansPalette returns a palette of k colors which represents a group of weighted colors.
+// The initial colors are chosen by repeatedly picking the color furthest from the ones already chosen,
+// and are then refined with a number of iterations of k-means.
+// If k isn't positive or there are no colors, nil is returned.
+func KMeansPalette(colors []RGB, weights []float64, k, iterations int) Palette {
+	if k <= 0 || len(colors) == 0 {
+		return nil
+	}
+
+	palette := Palette{}
+
+	// Start with the color which has the highest weight
+	first := 0
+	for i, w := range weights {
+		if w > weights[first] {
+			first = i
+		}
+	}
+	palette = append(palette, colors[first])
+
+	for len(palette) < k {
+		furthest := -1
+		furthestDist := 0.
+
+		for i, c := range colors {
+			dist := DistSq(c, palette[palette.Nearest(c)]) * weights[i]
+			if dist > furthestDist {
+				furthest = i
+				furthestDist = dist
+			}
+		}
+
+		// All the colors are already in the palette
+		if furthest == -1 {
+			break
+		}
+
+		palette = append(palette, colors[furthest])
+	}
+
+	palette.Refine(colors, weights, iterations)
+
+	return palette
+}
+
+// NearestLuminance returns the index of the color in the palette with the closest luminance to l.
+func (p Palette) NearestLuminance(l float64) int {
+	nearest := 0
+	nearestDist := -1.
+
+	for i, c := range p {
+		if dist := math.Abs(Luminance(c) - l); nearestDist == -1 || dist < nearestDist {
+			nearest = i
+			nearestDist = dist
+		}
+	}
+
+	return nearest
+}
+
+// Match returns the index of the color in the palette a color is drawn with: the closest color, or the color
+// with the closest luminance if grayscale is true (like fitness functions using fitness.WithGrayscale).
+func (p Palette) Match(rgb RGB, grayscale bool) int {
+	if grayscale {
+		return p.NearestLuminance(Luminance(rgb))
+	}
+	return p.Nearest(rgb)
+}
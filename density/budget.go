@@ -0,0 +1,52 @@
+package density
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// Budget is a number of points which are placed within a rectangle of the image, so an area can be given
+// more or fewer points than its density would give it. Points are assigned to budgets in order: the first
+// budget has the first Points points of a point group, the next budget has the points after, and so on.
+type Budget struct {
+	Min, Max normgeom.NormPoint // The corners of the rectangle.
+	Points   int
+}
+
+// Contains returns if a point is inside the rectangle of the budget.
+func (b Budget) Contains(p normgeom.NormPoint) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X && p.Y >= b.Min.Y && p.Y <= b.Max.Y
+}
+
+// Constrain moves a point to the nearest point inside the rectangle of the budget.
+func (b Budget) Constrain(p *normgeom.NormPoint) {
+	p.X = clampFloat(p.X, b.Min.X, b.Max.X)
+	p.Y = clampFloat(p.Y, b.Min.Y, b.Max.Y)
+}
+
+// BudgetOf returns the budget the point at an index belongs to, or false if it isn't part of any budget.
+func BudgetOf(budgets []Budget, i int) (Budget, bool) {
+	for _, b := range budgets {
+		if i < b.Points {
+			return b, true
+		}
+		i -= b.Points
+	}
+	return Budget{}, false
+}
+
+// TotalPoints returns the number of points in all the budgets.
+func TotalPoints(budgets []Budget) int {
+	total := 0
+	for _, b := range budgets {
+		total += b.Points
+	}
+	return total
+}
+
+func clampFloat(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,213 @@
+// Package density implements maps of how densely points should be placed across an image.
+package density
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"math"
+)
+
+// Map stores the density of each pixel of an image, where areas with a higher density should have more points.
+// Densities are non-negative, and only their relative values matter.
+type Map struct {
+	values        []float64
+	width, height int
+}
+
+// NewMap returns a Map of a specified size where every pixel has a density of 1.
+func NewMap(w, h int) Map {
+	m := Map{values: make([]float64, w*h), width: w, height: h}
+	for i := range m.values {
+		m.values[i] = 1
+	}
+	return m
+}
+
+// Size returns the width and height of the map.
+func (m Map) Size() (int, int) {
+	return m.width, m.height
+}
+
+// At returns the density of a pixel.
+func (m Map) At(x, y int) float64 {
+	return m.values[y*m.width+x]
+}
+
+// Set sets the density of a pixel.
+func (m Map) Set(x, y int, density float64) {
+	m.values[y*m.width+x] = density
+}
+
+// AtNorm returns the density of the pixel at a normalized point.
+func (m Map) AtNorm(p normgeom.NormPoint) float64 {
+	x := int(p.X * float64(m.width))
+	y := int(p.Y * float64(m.height))
+
+	if x >= m.width {
+		x = m.width - 1
+	}
+	if y >= m.height {
+		y = m.height - 1
+	}
+
+	return m.At(x, y)
+}
+
+// Max returns the highest density of the map.
+func (m Map) Max() float64 {
+	max := 0.
+	for _, v := range m.values {
+		max = math.Max(max, v)
+	}
+	return max
+}
+
+// Mean returns the average density of the map.
+func (m Map) Mean() float64 {
+	if len(m.values) == 0 {
+		return 0
+	}
+
+	sum := 0.
+	for _, v := range m.values {
+		sum += v
+	}
+	return sum / float64(len(m.values))
+}
+
+// FromImage returns a Map painted by an artist as a grayscale image, where brighter pixels have a higher density.
+// Black pixels have a density of floor, relative to a density of 1 for white pixels.
+func FromImage(img image.Data, floor float64) Map {
+	w, h := img.Size()
+
+	m := Map{values: make([]float64, w*h), width: w, height: h}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.values[y*w+x] = floor + (1-floor)*color.Luminance(img.RGBAt(x, y))
+		}
+	}
+
+	return m
+}
+
+// FromDetail returns a Map where areas of an image with more detail (a larger change in luminance between
+// neighboring pixels) have a higher density. The detail is blurred by a radius so the density changes smoothly,
+// and areas without any detail have a density of floor, relative to a density of 1 for the most detailed area.
+func FromDetail(img image.Data, radius int, floor float64) Map {
+	w, h := img.Size()
+	at := luminance(img)
+
+	m := Map{values: make([]float64, w*h), width: w, height: h}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dX := at(x+1, y) - at(x-1, y)
+			dY := at(x, y+1) - at(x, y-1)
+			m.values[y*w+x] = math.Sqrt(dX*dX + dY*dY)
+		}
+	}
+
+	m.blur(radius)
+	m.scale(floor)
+
+	return m
+}
+
+// FromSobel returns a Map where the density of each pixel is the strength of the edge at the pixel, calculated
+// with the Sobel operator on the luminance of an image. Pixels which aren't on an edge have a density of floor,
+// relative to a density of 1 for the strongest edge.
+func FromSobel(img image.Data, floor float64) Map {
+	w, h := img.Size()
+	at := luminance(img)
+
+	m := Map{values: make([]float64, w*h), width: w, height: h}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dX := (at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1)) - (at(x-1, y-1) + 2*at(x-1, y) + at(x-1, y+1))
+			dY := (at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1)) - (at(x-1, y-1) + 2*at(x, y-1) + at(x+1, y-1))
+			m.values[y*w+x] = math.Sqrt(dX*dX + dY*dY)
+		}
+	}
+
+	m.scale(floor)
+
+	return m
+}
+
+// luminance returns a function which returns the luminance of a pixel of an image,
+// where pixels outside the image have the luminance of the nearest pixel.
+func luminance(img image.Data) func(x, y int) float64 {
+	w, h := img.Size()
+
+	values := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			values[y*w+x] = color.Luminance(img.RGBAt(x, y))
+		}
+	}
+
+	return func(x, y int) float64 {
+		x = clamp(x, 0, w-1)
+		y = clamp(y, 0, h-1)
+		return values[y*w+x]
+	}
+}
+
+// scale scales the densities between floor and 1, or sets them all to 1 if they're all 0.
+func (m Map) scale(floor float64) {
+	if max := m.Max(); max > 0 {
+		for i, v := range m.values {
+			m.values[i] = floor + (1-floor)*v/max
+		}
+	} else {
+		for i := range m.values {
+			m.values[i] = 1
+		}
+	}
+}
+
+// blur applies a box blur with a radius to the map, horizontally and then vertically.
+func (m Map) blur(radius int) {
+	if radius <= 0 {
+		return
+	}
+
+	w, h := m.width, m.height
+	tmp := make([]float64, len(m.values))
+
+	blurLine := func(src, dst []float64, n, stride, offset int) {
+		sum, count := 0., 0
+		for i := 0; i < radius && i < n; i++ {
+			sum += src[offset+i*stride]
+			count++
+		}
+		for i := 0; i < n; i++ {
+			if j := i + radius; j < n {
+				sum += src[offset+j*stride]
+				count++
+			}
+			if j := i - radius - 1; j >= 0 {
+				sum -= src[offset+j*stride]
+				count--
+			}
+			dst[offset+i*stride] = sum / float64(count)
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		blurLine(m.values, tmp, w, 1, y*w)
+	}
+	for x := 0; x < w; x++ {
+		blurLine(tmp, m.values, h, w, x)
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
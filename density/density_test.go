@@ -0,0 +1,80 @@
+package density
+
+import (
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/stretchr/testify/assert"
+	stdimage "image"
+	"image/color"
+	"testing"
+)
+
+func TestFromDetail(t *testing.T) {
+	// An image with a vertical edge in the middle
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 40, 10))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 10; y++ {
+			if x >= 20 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	m := FromDetail(image.ToData(img), 2, 0.1)
+
+	assert.InDelta(t, m.Max(), 1, 1e-9)
+	assert.InDelta(t, m.At(0, 5), 0.1, 1e-9)
+	assert.True(t, m.At(20, 5) > m.At(17, 5))
+	assert.True(t, m.At(17, 5) > m.At(0, 5))
+	assert.Equal(t, m.AtNorm(normgeom.NormPoint{X: 1, Y: 1}), m.At(39, 9))
+}
+
+func TestFromSobel(t *testing.T) {
+	// An image with a vertical edge in the middle
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 40, 10))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 10; y++ {
+			if x >= 20 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	m := FromSobel(image.ToData(img), 0.1)
+
+	assert.InDelta(t, m.At(19, 5), 1, 1e-9)
+	assert.InDelta(t, m.At(20, 5), 1, 1e-9)
+	assert.InDelta(t, m.At(18, 5), 0.1, 1e-9)
+	assert.InDelta(t, m.At(0, 0), 0.1, 1e-9)
+}
+
+func TestFromImage(t *testing.T) {
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.Black)
+	img.Set(1, 0, color.White)
+
+	m := FromImage(image.ToData(img), 0.2)
+	assert.InDelta(t, m.At(0, 0), 0.2, 1e-9)
+	assert.InDelta(t, m.At(1, 0), 1, 1e-9)
+	assert.InDelta(t, m.Mean(), 0.6, 1e-9)
+}
+
+func TestBudgetOf(t *testing.T) {
+	budgets := []Budget{{Points: 2}, {Min: normgeom.NormPoint{X: 0.5}, Max: normgeom.NormPoint{X: 1, Y: 1}, Points: 3}}
+
+	b, ok := BudgetOf(budgets, 3)
+	assert.True(t, ok)
+	assert.Equal(t, b, budgets[1])
+
+	_, ok = BudgetOf(budgets, 5)
+	assert.False(t, ok)
+	assert.Equal(t, TotalPoints(budgets), 5)
+
+	p := normgeom.NormPoint{X: 0.2, Y: 0.4}
+	b.Constrain(&p)
+	assert.Equal(t, p, normgeom.NormPoint{X: 0.5, Y: 0.4})
+}
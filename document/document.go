@@ -0,0 +1,202 @@
+// Package document implements a versioned, self-describing format for the output of an algorithm.
+package document
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RH12503/Triangula/algorithm"
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/random"
+	"image"
+	stdcolor "image/color"
+	"io"
+	"io/ioutil"
+	"math/rand"
+)
+
+// Version is the version of the format written by Write.
+const Version = 1
+
+// Mode is the type of shapes the points of a document are turned into.
+type Mode string
+
+const (
+	Triangles     Mode = "triangles" // A Delaunay triangulation of the points.
+	Polygons      Mode = "polygons"  // The polygons of polygonation.Polygonate.
+	PowerPolygons Mode = "power"     // The polygons of polygonation.PowerPolygonate.
+)
+
+// Document stores the result of an algorithm, along with what's needed to render and reproduce it.
+type Document struct {
+	Version int                     `json:"version"`
+	Mode    Mode                    `json:"mode"`
+	Points  normgeom.NormPointGroup `json:"points"`
+	Pinned  []int                   `json:"pinned,omitempty"` // The indexes of the points which are pinned.
+
+	Source Source `json:"source"`
+	Params Params `json:"params"`
+	Seed   int64  `json:"seed"` // The seed applied with SeedRandom before the algorithm was created.
+	Stats  Stats  `json:"stats"`
+
+	// The colors of the shapes in the order they're created from the points, or nil if they aren't stored.
+	Colors []color.RGB `json:"colors,omitempty"`
+}
+
+// Source describes the image an algorithm was run on.
+type Source struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Hash   string `json:"hash,omitempty"` // The hash of the pixels of the image (see NewSource).
+}
+
+// Params stores the parameters of an algorithm.
+type Params struct {
+	Algorithm  string  `json:"algorithm,omitempty"` // The name of the algorithm, such as "default".
+	Points     int     `json:"points"`
+	Mutations  int     `json:"mutations,omitempty"`
+	Variation  float64 `json:"variation,omitempty"`
+	Population int     `json:"population,omitempty"`
+	Cutoff     int     `json:"cutoff,omitempty"`
+	Cache      int     `json:"cache,omitempty"` // The total size of the caches in bytes (see evaluator.NewParallelSize).
+
+	// Other parameters of the algorithm, by name.
+	Other map[string]float64 `json:"other,omitempty"`
+}
+
+// Stats stores the progress of an algorithm when the document was created.
+type Stats struct {
+	Fitness    float64 `json:"fitness"`
+	Generation int     `json:"generation"`
+}
+
+// legacyOutput is the format written for algorithms with pinned points before documents were versioned.
+type legacyOutput struct {
+	Points normgeom.NormPointGroup
+	Pinned []int
+}
+
+// NewSource returns the Source of an image. The hash is the SHA-256 hash of its size and 8-bit RGBA pixels,
+// so it doesn't depend on how the image was encoded.
+func NewSource(img image.Image) Source {
+	b := img.Bounds()
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%vx%v\n", b.Dx(), b.Dy())
+
+	row := make([]byte, 0, b.Dx()*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := stdcolor.NRGBAModel.Convert(img.At(x, y)).(stdcolor.NRGBA)
+			row = append(row, c.R, c.G, c.B, c.A)
+		}
+		h.Write(row)
+	}
+
+	return Source{
+		Width:  b.Dx(),
+		Height: b.Dy(),
+		Hash:   "sha256:" + hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+// SeedRandom seeds the random number generators used by the generators, mutation methods and algorithms
+// (math/rand and the random package). Calling it with the seed of a document before creating the algorithm
+// makes the run repeatable, so the seed should be set by the caller and applied with SeedRandom.
+func SeedRandom(seed int64) {
+	rand.Seed(seed)
+	random.Seed(seed)
+}
+
+// FromAlgorithm returns a Document with the best point group of an algorithm, its pinned points and its stats.
+// The other fields, including the seed, aren't known by the algorithm and need to be set by the caller.
+func FromAlgorithm(algo algorithm.Algorithm, mode Mode) Document {
+	stats := algo.Stats()
+
+	d := Document{
+		Version: Version,
+		Mode:    mode,
+		Points:  algo.Best(),
+		Stats: Stats{
+			Fitness:    stats.BestFitness,
+			Generation: stats.Generation,
+		},
+	}
+
+	if p, ok := algo.(algorithm.PinnedAlgorithm); ok && p.Pins().Count() > 0 {
+		d.Pinned = p.Pins().Indexes()
+	}
+
+	return d
+}
+
+// Write writes a document as JSON, with the current version.
+func Write(w io.Writer, d Document) error {
+	d.Version = Version
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Read reads a document written by Write. Outputs written before documents were versioned (a bare array of
+// points, or an object with the points and pinned points) are migrated to a document of triangles,
+// which is missing the information the old formats didn't store.
+func Read(r io.Reader) (Document, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Document{}, errors.New("document is empty")
+	}
+
+	// A bare array of points
+	if data[0] == '[' {
+		var points normgeom.NormPointGroup
+		if err := json.Unmarshal(data, &points); err != nil {
+			return Document{}, err
+		}
+		return Document{Version: Version, Mode: Triangles, Points: points}, nil
+	}
+
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return Document{}, err
+	}
+
+	switch {
+	case version.Version == 0:
+		var legacy legacyOutput
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return Document{}, err
+		}
+		return Document{Version: Version, Mode: Triangles, Points: legacy.Points, Pinned: legacy.Pinned}, nil
+	case version.Version > Version:
+		return Document{}, fmt.Errorf("document version %v is newer than the supported version %v", version.Version, Version)
+	}
+
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Document{}, err
+	}
+
+	if d.Mode == "" {
+		d.Mode = Triangles
+	}
+
+	return d, nil
+}
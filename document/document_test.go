@@ -0,0 +1,90 @@
+package document
+
+import (
+	"bytes"
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/generator"
+	"github.com/RH12503/Triangula/mutation"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/stretchr/testify/assert"
+	"image"
+	stdcolor "image/color"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	d := Document{
+		Mode:   Polygons,
+		Points: normgeom.NormPointGroup{{X: 0.1, Y: 0.2}, {X: 0.5, Y: 1}},
+		Pinned: []int{1},
+		Source: Source{Width: 100, Height: 50, Hash: "sha256:00"},
+		Params: Params{Algorithm: "default", Points: 2, Population: 400, Other: map[string]float64{"edgePoints": 4}},
+		Seed:   42,
+		Stats:  Stats{Fitness: 0.9, Generation: 10},
+		Colors: []color.RGB{{R: 1}},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, Write(&b, d))
+
+	read, err := Read(&b)
+	assert.NoError(t, err)
+
+	d.Version = Version
+	assert.Equal(t, read, d)
+}
+
+func TestReadLegacy(t *testing.T) {
+	points := normgeom.NormPointGroup{{X: 0.1, Y: 0.2}, {X: 0.5, Y: 1}}
+
+	d, err := Read(strings.NewReader(` [{"X":0.1,"Y":0.2},{"X":0.5,"Y":1}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, d, Document{Version: Version, Mode: Triangles, Points: points})
+
+	d, err = Read(strings.NewReader(`{"Points":[{"X":0.1,"Y":0.2},{"X":0.5,"Y":1}],"Pinned":[0]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, d, Document{Version: Version, Mode: Triangles, Points: points, Pinned: []int{0}})
+
+	_, err = Read(strings.NewReader(`{"version":2}`))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestSeedRandom(t *testing.T) {
+	gen := generator.RandomGenerator{}
+
+	SeedRandom(7)
+	points := gen.Generate(10)
+	mutated := points.Copy()
+	mutation.DefaultGaussianMethod(10).Mutate(mutated, func(mutation.Mutation) {})
+
+	SeedRandom(7)
+	assert.Equal(t, gen.Generate(10), points)
+
+	other := points.Copy()
+	mutation.DefaultGaussianMethod(10).Mutate(other, func(mutation.Mutation) {})
+	assert.Equal(t, other, mutated)
+}
+
+func TestNewSource(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	s := NewSource(img)
+
+	assert.Equal(t, s.Width, 3)
+	assert.Equal(t, s.Height, 2)
+	assert.True(t, strings.HasPrefix(s.Hash, "sha256:"))
+
+	// The same pixels in a different image type have the same hash
+	gray := image.NewGray(image.Rect(5, 5, 8, 7))
+	for y := 5; y < 7; y++ {
+		for x := 5; x < 8; x++ {
+			gray.Set(x, y, stdcolor.Black)
+			img.Set(x-5, y-5, stdcolor.Black)
+		}
+	}
+	assert.Equal(t, NewSource(gray), NewSource(img))
+	assert.NotEqual(t, NewSource(img).Hash, s.Hash)
+}
@@ -5,29 +5,207 @@ type CacheFunction interface {
 	Function
 
 	SetBase(function CacheFunction)
+
+	// Cache returns the data used in the last calculation, so it can be added to a Cache.
 	Cache() []CacheData
-	SetCache([]CacheData)
+
+	// SetCache sets the Cache which is read from during calculations.
+	SetCache(cache *Cache)
+
+	// TakeStats returns the cache hits and misses since the last call to TakeStats.
+	TakeStats() CacheStats
 }
 
+// CacheData represents data which can be stored in a Cache.
 type CacheData interface {
 	Equals(data CacheData) bool
 	Hash() uint64
 	Data() float64
-	CachedHash() uint32
-	SetCachedHash(uint322 uint32)
 }
 
-// TriangleCacheData stores the triangles vertices and its fitness, and is used to cache calculations.
+// CacheEntrySize is the approximate number of bytes a cached triangle uses, including the data it points to.
+const CacheEntrySize = 96
+
+// cacheWays is the number of entries in each set of a Cache.
+const cacheWays = 4
+
+// Cache is a set-associative cache of calculated fitness data.
+// Data is mapped to a set of cacheWays entries using its hash, and when a set is full an entry
+// is evicted using the CLOCK algorithm so data which is still being used tends to be kept.
+//
+// Get can be called concurrently, but not at the same time as Put.
+type Cache struct {
+	entries []cacheEntry
+	hands   []uint8 // The clock hand of each set.
+	sets    uint64
+
+	evictions uint64
+}
+
+// cacheEntry is a single slot in a Cache.
+type cacheEntry struct {
+	hash       uint64
+	data       CacheData
+	referenced bool // Set when the data is put into the cache, and cleared by the clock hand.
+}
+
+// NewCache returns a Cache which uses at most a specified number of bytes.
+// The cache always has at least one set.
+func NewCache(bytes int) *Cache {
+	sets := bytes / (cacheWays * CacheEntrySize)
+	if sets < 1 {
+		sets = 1
+	}
+
+	return &Cache{
+		entries: make([]cacheEntry, sets*cacheWays),
+		hands:   make([]uint8, sets),
+		sets:    uint64(sets),
+	}
+}
+
+// set returns the index of the set data with a hash belongs to.
+func (c *Cache) set(hash uint64) int {
+	// Map the upper bits of the hash onto [0, sets) without a modulo
+	return int(((hash >> 32) * c.sets) >> 32)
+}
+
+// Get returns cached data equal to data, and whether it was found.
+// Calling Get on a nil Cache always returns false.
+func (c *Cache) Get(data CacheData) (CacheData, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	hash := data.Hash()
+	i := c.set(hash) * cacheWays
+
+	for _, e := range c.entries[i : i+cacheWays] {
+		if e.data != nil && e.hash == hash && e.data.Equals(data) {
+			return e.data, true
+		}
+	}
+
+	return nil, false
+}
+
+// Put adds data to the cache, evicting other data from its set if necessary.
+func (c *Cache) Put(data CacheData) {
+	hash := data.Hash()
+	s := c.set(hash)
+	set := c.entries[s*cacheWays : (s+1)*cacheWays]
+
+	for i := range set {
+		e := &set[i]
+		if e.data == nil {
+			*e = cacheEntry{hash: hash, data: data, referenced: true}
+			return
+		}
+		if e.hash == hash && e.data.Equals(data) {
+			e.referenced = true
+			return
+		}
+	}
+
+	// The set is full, so advance the clock hand until an entry which hasn't been
+	// referenced since the hand last passed it is found
+	hand := &c.hands[s]
+	for {
+		e := &set[*hand]
+		*hand = (*hand + 1) % cacheWays
+
+		if e.referenced {
+			e.referenced = false
+		} else {
+			*e = cacheEntry{hash: hash, data: data, referenced: true}
+			c.evictions++
+			return
+		}
+	}
+}
+
+// Evictions returns the number of entries which have been evicted from the cache.
+func (c *Cache) Evictions() uint64 {
+	return c.evictions
+}
+
+// CacheStats stores statistics relating to the use of a Cache.
+type CacheStats struct {
+	Hits, Misses uint64
+	Evictions    uint64
+}
+
+// Add adds the statistics of another CacheStats.
+func (s *CacheStats) Add(other CacheStats) {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Evictions += other.Evictions
+}
+
+// HitRate returns the fraction of lookups which were hits.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// mixHash combines a hash with a value using the finalizer of SplitMix64, so
+// similar values result in very different hashes.
+func mixHash(hash, v uint64) uint64 {
+	hash ^= v
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash
+}
+
+// packCoords packs two coordinates into a uint64.
+func packCoords(x, y int32) uint64 {
+	return uint64(uint32(x))<<32 | uint64(uint32(y))
+}
+
+// TriangleCacheData stores the triangles vertices and data about its pixels, and is used to cache calculations.
 type TriangleCacheData struct {
-	aX, aY  int16
-	bX, bY  int16
-	cX, cY  int16
-	fitness float64
-	hash    uint32
+	aX, aY int32
+	bX, bY int32
+	cX, cY int32
+	shape  shapeData
+	hash   uint64
 }
 
+// newTriangleCacheData returns a TriangleCacheData with its vertices in a consistent order,
+// so the same triangle always has the same hash regardless of the order of its vertices.
+func newTriangleCacheData(aX, aY, bX, bY, cX, cY int32) *TriangleCacheData {
+	a, b, c := packCoords(aX, aY), packCoords(bX, bY), packCoords(cX, cY)
+
+	if a > b {
+		a, b = b, a
+		aX, aY, bX, bY = bX, bY, aX, aY
+	}
+	if b > c {
+		b, c = c, b
+		bX, bY, cX, cY = cX, cY, bX, bY
+	}
+	if a > b {
+		a, b = b, a
+		aX, aY, bX, bY = bX, bY, aX, aY
+	}
+
+	return &TriangleCacheData{
+		aX: aX, aY: aY,
+		bX: bX, bY: bY,
+		cX: cX, cY: cY,
+		hash: mixHash(mixHash(mixHash(0, a), b), c),
+	}
+}
+
+// Data returns the error of the triangle.
 func (t TriangleCacheData) Data() float64 {
-	return t.fitness
+	return t.shape.diff
 }
 
 // Equals returns if the TriangleCacheData is equal to another.
@@ -38,41 +216,38 @@ func (t TriangleCacheData) Equals(other CacheData) bool {
 		t.cX == tri.cX && t.cY == tri.cY
 }
 
-// Hash calculates the hash code of a TriangleCacheData.
+// Hash returns the hash code of a TriangleCacheData.
 func (t TriangleCacheData) Hash() uint64 {
-	x := int(t.aX) + int(t.bX) + int(t.cX)
-	y := int(t.aY) + int(t.bY) + int(t.cY)
-
-	return uint64((97+x)*97 + y)
-}
-
-func (t TriangleCacheData) CachedHash() uint32 {
 	return t.hash
 }
 
-func (t *TriangleCacheData) SetCachedHash(hash uint32) {
-	t.hash = hash
-}
-
+// PolygonCacheData stores the vertices of a polygon and data about its pixels, and is used to cache calculations.
 type PolygonCacheData struct {
-	coords  []int16
-	fitness float64
-	hash    uint32
+	coords []int32
+	shape  shapeData
+	hash   uint64
 }
 
-func (p PolygonCacheData) CachedHash() uint32 {
-	return p.hash
-}
+// newPolygonCacheData returns a PolygonCacheData given the coordinates of a polygon's vertices.
+func newPolygonCacheData(coords []int32) *PolygonCacheData {
+	hash := uint64(len(coords))
+
+	for i := 0; i+1 < len(coords); i += 2 {
+		hash = mixHash(hash, packCoords(coords[i], coords[i+1]))
+	}
 
-func (p *PolygonCacheData) SetCachedHash(hash uint32) {
-	p.hash = hash
+	return &PolygonCacheData{
+		coords: coords,
+		hash:   hash,
+	}
 }
 
+// Data returns the error of the polygon.
 func (p PolygonCacheData) Data() float64 {
-	return p.fitness
+	return p.shape.diff
 }
 
-// Equals returns if the TriangleCacheData is equal to another.
+// Equals returns if the PolygonCacheData is equal to another.
 func (p PolygonCacheData) Equals(other CacheData) bool {
 
 	poly := other.(*PolygonCacheData)
@@ -87,14 +262,7 @@ func (p PolygonCacheData) Equals(other CacheData) bool {
 	return true
 }
 
-// Hash calculates the hash code of a TriangleCacheData.
+// Hash returns the hash code of a PolygonCacheData.
 func (p PolygonCacheData) Hash() uint64 {
-
-	hash := uint64(1)
-
-	for i := 0; i < len(p.coords); i++ {
-		hash = hash*97 + uint64(p.coords[i])
-	}
-
-	return hash
+	return p.hash
 }
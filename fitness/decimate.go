@@ -0,0 +1,261 @@
+package fitness
+
+import (
+	"github.com/RH12503/Triangula/geom"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/rasterize"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"math"
+)
+
+// decimator removes the points of a triangulation which barely affect its error.
+type decimator struct {
+	options *options
+	shape   Shape // Reused to store the pixels of each triangle.
+
+	maxDifference float64 // The maximum difference of all pixels to the target image.
+
+	triangulation *incrdelaunay.Delaunay
+	trial         *incrdelaunay.Delaunay // A copy of the triangulation which points are removed from to find their cost.
+
+	// The triangles of the triangulation, used to find the triangles created by removing a point.
+	triangles map[[3]incrdelaunay.Point]bool
+
+	star, created []incrdelaunay.Triangle // For performance purposes.
+}
+
+// Decimate removes points from a triangulated point group (such as the output of an Algorithm) whose removal
+// changes the error to the target image by less than a threshold, giving a smaller group of points with a
+// near-identical appearance. The threshold is in units of fitness, so each removed point lowers the fitness of
+// the triangles by at most the threshold. The points which change the error the least are removed first,
+// and the Option's should be the ones used to create the point group.
+//
+// Pinned points are never removed, so points on the border (see normgeom.Border) should also be pinned to keep
+// them. Points at the same position as another point don't change the error, so they're removed first.
+// The remaining points are returned alongside their pins.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func Decimate(points normgeom.NormPointGroup, pins normgeom.Pins, target image.Data, threshold float64,
+	opts ...Option) (normgeom.NormPointGroup, normgeom.Pins) {
+
+	checkSize(target)
+
+	w, h := target.Size()
+
+	options := newOptions(opts)
+	pixels := options.pixelData(target)
+
+	d := decimator{
+		options:       options,
+		shape:         newShape(pixels),
+		maxDifference: options.maxPixelError() * float64(w*h),
+		triangulation: incrdelaunay.NewDelaunay(w, h),
+		trial:         incrdelaunay.NewDelaunay(w, h),
+		triangles:     map[[3]incrdelaunay.Point]bool{},
+	}
+
+	vertices := make([]incrdelaunay.Point, len(points))
+	indexes := map[incrdelaunay.Point][]int{} // The indexes of the points at each position
+	counts := map[incrdelaunay.Point]int{}    // The number of points which haven't been removed at each position
+
+	for i, p := range points {
+		vertices[i] = createPoint(p.X, p.Y, w, h)
+		d.triangulation.Insert(vertices[i])
+		indexes[vertices[i]] = append(indexes[vertices[i]], i)
+		counts[vertices[i]]++
+	}
+	d.storeTriangles()
+	d.trial.Set(d.triangulation)
+
+	// The change in error of removing each point, which only changes for the neighbors of a removed point
+	costs := make([]float64, len(points))
+	dirty := make([]bool, len(points))
+	removed := make([]bool, len(points))
+
+	for i := range points {
+		dirty[i] = true
+	}
+
+	for {
+		best := -1
+		for i := range points {
+			if removed[i] || pins.Pinned(i) {
+				continue
+			}
+			if dirty[i] {
+				if counts[vertices[i]] > 1 {
+					// Removing a duplicate doesn't change the triangulation
+					costs[i] = 0
+				} else {
+					costs[i] = d.cost(vertices[i])
+				}
+				dirty[i] = false
+			}
+			if best == -1 || costs[i] < costs[best] {
+				best = i
+			}
+		}
+
+		if best == -1 || costs[best] >= threshold {
+			break
+		}
+
+		p := vertices[best]
+		removed[best] = true
+		counts[p]--
+
+		if counts[p] > 0 {
+			// The other points at the same position are no longer duplicates if only one is left
+			for _, i := range indexes[p] {
+				dirty[i] = true
+			}
+			d.triangulation.Remove(p)
+			d.trial.Remove(p)
+			continue
+		}
+
+		star, created := d.remove(d.triangulation, p)
+		d.trial.Remove(p)
+
+		// The points sharing a triangle with the removed point have different triangles around them
+		for _, t := range star {
+			for _, v := range [3]incrdelaunay.Point{t.A, t.B, t.C} {
+				for _, i := range indexes[v] {
+					dirty[i] = true
+				}
+			}
+			delete(d.triangles, triangleKey(t))
+		}
+
+		for _, t := range created {
+			d.triangles[triangleKey(t)] = true
+		}
+	}
+
+	var decimated normgeom.NormPointGroup
+	var decimatedPins normgeom.Pins
+
+	for i, p := range points {
+		if !removed[i] {
+			decimated = append(decimated, p)
+			decimatedPins = append(decimatedPins, pins.Pinned(i))
+		}
+	}
+
+	return decimated, decimatedPins
+}
+
+// storeTriangles stores the triangles of the triangulation.
+func (d *decimator) storeTriangles() {
+	d.triangulation.IterTriangles(func(t incrdelaunay.Triangle) {
+		d.triangles[triangleKey(t)] = true
+	})
+}
+
+// remove removes a point from a triangulation with the same triangles as d.triangles, returning the triangles
+// which had the point as a vertex and the triangles created in their place. The slices are reused by the next call.
+func (d *decimator) remove(triangulation *incrdelaunay.Delaunay, p incrdelaunay.Point) (star,
+	created []incrdelaunay.Triangle) {
+
+	d.star = d.star[:0]
+	triangulation.IterVertexTriangles(p, func(t incrdelaunay.Triangle) {
+		d.star = append(d.star, t)
+	})
+
+	triangulation.Remove(p)
+
+	// The created triangles are around the neighbors of the point, and weren't in the triangulation before
+	d.created = d.created[:0]
+	for _, t := range d.star {
+		for _, v := range [3]incrdelaunay.Point{t.A, t.B, t.C} {
+			if v == p {
+				continue
+			}
+			triangulation.IterVertexTriangles(v, func(n incrdelaunay.Triangle) {
+				if key := triangleKey(n); !d.triangles[key] && !containsTriangle(d.created, key) {
+					d.created = append(d.created, n)
+				}
+			})
+		}
+	}
+
+	return d.star, d.created
+}
+
+// cost returns how much the fitness decreases when a point is removed from the triangulation.
+// The point is removed from the trial triangulation and then inserted again, which only changes the
+// triangles around it.
+func (d *decimator) cost(p incrdelaunay.Point) float64 {
+	difference := 0.
+
+	star, created := d.remove(d.trial, p)
+
+	for _, t := range star {
+		difference -= d.triangleError(t)
+	}
+	for _, t := range created {
+		difference += d.triangleError(t)
+	}
+
+	d.trial.Insert(p)
+
+	// Inserting the point restores the same triangles, unless it has cocircular neighbors which can be
+	// triangulated in multiple ways
+	restored := true
+	n := 0
+	d.trial.IterVertexTriangles(p, func(t incrdelaunay.Triangle) {
+		restored = restored && d.triangles[triangleKey(t)]
+		n++
+	})
+	if !restored || n != len(star) {
+		d.trial.Set(d.triangulation)
+	}
+
+	return difference / d.maxDifference
+}
+
+// triangleKey returns the sorted vertices of a triangle, so a triangle has the same key regardless of the
+// order its vertices are stored in.
+func triangleKey(t incrdelaunay.Triangle) [3]incrdelaunay.Point {
+	a, b, c := t.A, t.B, t.C
+	if lessPoint(b, a) {
+		a, b = b, a
+	}
+	if lessPoint(c, b) {
+		b, c = c, b
+	}
+	if lessPoint(b, a) {
+		a, b = b, a
+	}
+	return [3]incrdelaunay.Point{a, b, c}
+}
+
+// containsTriangle returns if a group of triangles contains a triangle with a key.
+func containsTriangle(triangles []incrdelaunay.Triangle, key [3]incrdelaunay.Point) bool {
+	for _, t := range triangles {
+		if triangleKey(t) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// lessPoint returns if a point is before another, ordered by X and then Y.
+func lessPoint(a, b incrdelaunay.Point) bool {
+	return a.X < b.X || (a.X == b.X && a.Y < b.Y)
+}
+
+// triangleError returns the error of a triangle, minus the error of its area if it was left blank
+// (so removing points on the edge of the triangulation is penalized like in the fitness function).
+func (d *decimator) triangleError(t incrdelaunay.Triangle) float64 {
+	a, b, c := t.A, t.B, t.C
+
+	area := math.Abs(0.5 * ((float64(b.X-a.X) * float64(c.Y-a.Y)) - (float64(c.X-a.X) * float64(b.Y-a.Y))))
+
+	tri := geom.NewTriangle(int(a.X), int(a.Y), int(b.X), int(b.Y), int(c.X), int(c.Y))
+
+	d.shape.reset()
+	rasterize.DDATriangleLines(tri, d.shape.addLine)
+
+	return d.options.shapeError(d.options.shapeData(&d.shape)) - d.options.maxPixelError()*area
+}
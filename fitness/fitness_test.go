@@ -1,9 +1,14 @@
 package fitness
 
 import (
+	triColor "github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/geom"
 	image2 "github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/mutation"
 	"github.com/RH12503/Triangula/normgeom"
 	"github.com/RH12503/Triangula/random"
+	"github.com/RH12503/Triangula/rasterize"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
 	"github.com/stretchr/testify/assert"
 	"image"
 	"image/color"
@@ -13,8 +18,6 @@ import (
 
 const width, height = 100, 100
 
-const blockSize = 3
-
 func TestTrianglesImageEvaluator_Calculate(t *testing.T) {
 	random.Seed(0)
 
@@ -30,7 +33,7 @@ func TestTrianglesImageEvaluator_Calculate(t *testing.T) {
 		}
 	}
 
-	evaluator := NewTrianglesImageFunction(image2.ToData(img), blockSize)
+	evaluator := NewTrianglesImageFunction(image2.ToData(img))
 
 	assert.Equal(t, evaluator.Calculate(PointsData{
 		Points: normgeom.NormPointGroup{
@@ -42,15 +45,386 @@ func TestTrianglesImageEvaluator_Calculate(t *testing.T) {
 			{0.34, 0.19},
 		},
 		Mutations: nil,
-	}), 0.16173023698665479)
+	}), 0.16173019905252684)
+}
+
+func TestShapeSums_Variance(t *testing.T) {
+	random.Seed(0)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{
+				R: uint8(random.Intn(math.MaxUint8)),
+				G: uint8(random.Intn(math.MaxUint8)),
+				B: uint8(random.Intn(math.MaxUint8)),
+				A: math.MaxUint8,
+			})
+		}
+	}
+	data := image2.ToData(img)
+	pixels := fromImage(data)
+
+	tri := geom.NewTriangle(13, 12, 37, 54, 78, 15)
+
+	// The variance calculated using lines should be the same as calculating it pixel by pixel
+	var sums shapeSums
+	rasterize.DDATriangleLines(tri, func(x0, x1, y int) {
+		sums.addLine(pixels, x0, x1, y)
+	})
+
+	var r, g, b, sq, n float64
+	rasterize.DDATriangle(tri, func(x, y int) {
+		c := data.RGBAt(x, y)
+		r += c.R * 255
+		g += c.G * 255
+		b += c.B * 255
+		sq += c.R*255*c.R*255 + c.G*255*c.G*255 + c.B*255*c.B*255
+		n++
+	})
+
+	assert.Equal(t, sums.n, int(n))
+	assert.InDelta(t, sums.variance(), sq-(r*r+g*g+b*b)/n, 1e-6)
 }
 
 func TestPixels(t *testing.T) {
 	pixels := newPixelData(100, 50)
-	assert.Equal(t, len(pixels.pixels), 50)
-	assert.Equal(t, len(pixels.pixels[0]), 100)
+	assert.Equal(t, len(pixels.rows), 50)
+	assert.Equal(t, len(pixels.rows[0]), 101)
+}
+
+func TestPixelData_Line(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 1, color.RGBA{R: uint8(x), G: 2, B: 0, A: math.MaxUint8})
+	}
+	pixels := fromImage(image2.ToData(img))
+
+	sum, n := pixels.line(-2, 10, 1)
+	assert.Equal(t, sum, pixelSum{r: 6, g: 8, b: 0, sq: 14 + 16})
+	assert.Equal(t, n, 4)
+
+	sum, n = pixels.line(1, 3, 1)
+	assert.Equal(t, sum, pixelSum{r: 3, g: 4, b: 0, sq: 5 + 8})
+	assert.Equal(t, n, 2)
+
+	_, n = pixels.line(3, 1, 0)
+	assert.Equal(t, n, 0)
+
+	_, n = pixels.line(0, 4, 2)
+	assert.Equal(t, n, 0)
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(cacheWays * CacheEntrySize)
+
+	a := newTriangleCacheData(1, 2, 3, 4, 5, 6)
+	a.shape.diff = 12
+
+	_, ok := cache.Get(a)
+	assert.Equal(t, ok, false)
+
+	cache.Put(a)
+
+	// The order of the vertices shouldn't matter
+	data, ok := cache.Get(newTriangleCacheData(5, 6, 1, 2, 3, 4))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, data.Data(), 12.)
+
+	// The cache only has one set, so adding more triangles than its ways evicts one
+	for i := int32(0); i < cacheWays; i++ {
+		cache.Put(newTriangleCacheData(i, 0, 10, 10, 20, 0))
+	}
+	assert.Equal(t, cache.Evictions(), uint64(1))
+
+	var nilCache *Cache
+	_, ok = nilCache.Get(a)
+	assert.Equal(t, ok, false)
+}
+
+func TestCacheStats_HitRate(t *testing.T) {
+	stats := CacheStats{Hits: 3, Misses: 1}
+	assert.Equal(t, stats.HitRate(), 0.75)
+	assert.Equal(t, CacheStats{}.HitRate(), 0.)
+}
+
+func TestWithPalette(t *testing.T) {
+	random.Seed(0)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{
+				R: uint8(random.Intn(math.MaxUint8)),
+				G: uint8(random.Intn(math.MaxUint8)),
+				B: uint8(random.Intn(math.MaxUint8)),
+				A: math.MaxUint8,
+			})
+		}
+	}
+
+	points := PointsData{
+		Points: normgeom.NormPointGroup{
+			{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0.4, Y: 0.6},
+		},
+	}
+
+	free := NewTrianglesImageFunction(image2.ToData(img)).Calculate(points)
+	restricted := NewTrianglesImageFunction(image2.ToData(img), WithPalette(triColor.Palette{{R: 1, G: 1, B: 1}})).Calculate(points)
+
+	// Being restricted to a color which is far from the image increases the error
+	assert.Less(t, restricted, free)
+
+	// An empty palette has no color to match shapes with
+	assert.Panics(t, func() { WithPalette(triColor.Palette{}) })
+	assert.Panics(t, func() { WithPalette(nil) })
+}
+
+func TestRegularizer(t *testing.T) {
+	var good, sliver regularizer
+
+	// An (almost) equilateral triangle
+	good.add(incrdelaunay.Point{X: 0, Y: 0}, incrdelaunay.Point{X: 100, Y: 0}, incrdelaunay.Point{X: 50, Y: 87}, 4350)
+	good.add(incrdelaunay.Point{X: 0, Y: 0}, incrdelaunay.Point{X: 100, Y: 0}, incrdelaunay.Point{X: 50, Y: -87}, 4350)
+
+	sliver.add(incrdelaunay.Point{X: 0, Y: 0}, incrdelaunay.Point{X: 100, Y: 0}, incrdelaunay.Point{X: 50, Y: 2}, 100)
+	sliver.add(incrdelaunay.Point{X: 0, Y: 0}, incrdelaunay.Point{X: 100, Y: 0}, incrdelaunay.Point{X: 50, Y: -87}, 4350)
+
+	weights := Regularization{MinAngle: 1, AspectRatio: 1, AreaVariance: 1}
+
+	assert.InDelta(t, good.penalty(weights), 0, 0.01)
+	assert.Greater(t, sliver.penalty(weights), 1.)
+	assert.Equal(t, sliver.penalty(Regularization{}), 0.)
+}
+
+func TestMetrics(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 1))
+	for x, v := range []uint8{0, 10, 20, 30, 250} {
+		img.Set(x, 0, color.RGBA{R: v, A: math.MaxUint8})
+	}
+	shape := newShape(fromImage(image2.ToData(img)))
+	shape.addLine(-1, 10, 0)
+
+	assert.Equal(t, shape.Count(), 5)
+
+	l2 := L2Metric()
+	assert.InDelta(t, l2.Fill(&shape).R*255, 62, 1e-6)
+	assert.InDelta(t, l2.Error(&shape, l2.Fill(&shape)), shape.Variance(), 1e-6)
+
+	// The median is used by L1, so the outlier doesn't affect the fill
+	l1 := L1Metric()
+	assert.InDelta(t, l1.Fill(&shape).R*255, 20, 1e-6)
+	assert.InDelta(t, l1.Error(&shape, l1.Fill(&shape)), 270, 1e-6)
+
+	lInf := MaxMetric()
+	assert.InDelta(t, lInf.Fill(&shape).R*255, 125, 1e-6)
+	assert.InDelta(t, lInf.Error(&shape, lInf.Fill(&shape)), 125*5, 1e-6)
+
+	huber := HuberMetric(20. / 255)
+	fill := huber.Fill(&shape)
+	assert.True(t, fill.R*255 > 10 && fill.R*255 < 62)
+	assert.True(t, huber.Error(&shape, fill) <= huber.Error(&shape, l2.Fill(&shape)))
+
+	// The penalty of filling with another color should be an upper bound of the actual increase in error
+	for _, m := range []Metric{l2, l1, lInf, huber} {
+		best := m.Fill(&shape)
+		other := triColor.RGB{R: 0.5, G: 0.2, B: 0.1}
+		assert.True(t, m.Error(&shape, other) <= m.Error(&shape, best)+5*m.Penalty(best, other)+1e-6)
+	}
+}
+
+func TestWithMetric(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 20), G: 100, B: uint8(y * 20), A: math.MaxUint8})
+		}
+	}
+	data := image2.ToData(img)
+
+	points := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0.4, Y: 0.6}}
+
+	for _, m := range []Metric{L2Metric(), L1Metric(), HuberMetric(0.1), MaxMetric()} {
+		fit := NewTrianglesImageFunction(data, WithMetric(m)).Calculate(PointsData{Points: points})
+		assert.True(t, fit > 0 && fit < 1)
+	}
+}
+
+func TestWithGrayscale(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			// Colors with the same luminance, which are identical in grayscale
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.RGBA{R: 255, A: math.MaxUint8})
+			} else {
+				img.Set(x, y, color.RGBA{G: 130, A: math.MaxUint8})
+			}
+		}
+	}
+	data := image2.ToData(img)
+
+	pixels := fromImageGray(data)
+	shape := newShape(pixels)
+	shape.addLine(0, 10, 0)
+	assert.Equal(t, shape.Channels(), 1)
+	assert.InDelta(t, shape.Variance(), 0, 1e-6)
+
+	points := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+
+	gray := NewTrianglesImageFunction(data, WithGrayscale()).Calculate(PointsData{Points: points})
+	rgb := NewTrianglesImageFunction(data).Calculate(PointsData{Points: points})
+	assert.InDelta(t, gray, 1, 1e-6)
+	assert.True(t, rgb < gray)
+}
+
+func TestWithConstraints(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 2), G: uint8(y * 2), B: 50, A: math.MaxUint8})
+		}
+	}
+	data := image2.ToData(img)
+
+	constraints := []normgeom.NormLine{normgeom.NewNormLine(0.1, 0.5, 0.9, 0.5)}
+	a, b := incrdelaunay.Point{X: 10, Y: 50}, incrdelaunay.Point{X: 90, Y: 50}
+
+	// Returns if any edge of a triangulation crosses the constraint
+	crosses := func(function CacheFunction) bool {
+		crossed := false
+		function.(*trianglesImageFunction).Triangulation.IterTriangles(func(tri incrdelaunay.Triangle) {
+			for _, e := range [3][2]incrdelaunay.Point{{tri.A, tri.B}, {tri.B, tri.C}, {tri.C, tri.A}} {
+				if segmentsCross(e[0], e[1], a, b) {
+					crossed = true
+				}
+			}
+		})
+		return crossed
+	}
+
+	points := normgeom.NormPointGroup{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0.5, Y: 0.2}, {X: 0.5, Y: 0.8},
+	}
 
-	pixelsN := newPixelDataN(100, 50, blockSize)
-	assert.Equal(t, len(pixelsN.pixels), 50-blockSize+1)
-	assert.Equal(t, len(pixelsN.pixels[0]), 100-blockSize+1)
+	unconstrained := NewTrianglesImageFunction(data)
+	unconstrained.Calculate(PointsData{Points: points})
+	assert.True(t, crosses(unconstrained))
+
+	functions := TrianglesImageFunctions(data, 2, WithConstraints(constraints))
+	functions[0].Calculate(PointsData{Points: points})
+	assert.False(t, crosses(functions[0]))
+
+	// The constraint is kept when the triangulation is mutated from a base
+	mutated := points.Copy()
+	mutated[4] = normgeom.NormPoint{X: 0.5, Y: 0.9}
+	functions[1].Calculate(PointsData{Points: points})
+	functions[1].SetBase(functions[0])
+	functions[1].Calculate(PointsData{
+		Points:    mutated,
+		Mutations: []mutation.Mutation{{Old: points[4], New: mutated[4], Index: 4}},
+	})
+	assert.False(t, crosses(functions[1]))
+	assert.Equal(t, len(functions[1].(*trianglesImageFunction).Triangulation.Constraints()), 1)
+
+	// Crossing constraints are rejected when the fitness functions are created
+	assert.Panics(t, func() {
+		NewTrianglesImageFunction(data, WithConstraints(append(constraints, normgeom.NewNormLine(0.5, 0.1, 0.5, 0.9))))
+	})
+}
+
+// segmentsCross returns if the segments p1-p2 and p3-p4 properly intersect.
+func segmentsCross(p1, p2, p3, p4 incrdelaunay.Point) bool {
+	orient := func(a, b, c incrdelaunay.Point) int64 {
+		return int64(b.X-a.X)*int64(c.Y-a.Y) - int64(b.Y-a.Y)*int64(c.X-a.X)
+	}
+	d1, d2 := orient(p3, p4, p1), orient(p3, p4, p2)
+	d3, d4 := orient(p1, p2, p3), orient(p1, p2, p4)
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+func TestPowerImageFunctions(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 30; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 8), B: 50, A: math.MaxUint8})
+		}
+	}
+	data := image2.ToData(img)
+
+	points := normgeom.NormPointGroup{
+		{X: 0.1, Y: 0.2}, {X: 0.7, Y: 0.3}, {X: 0.4, Y: 0.8}, {X: 0.9, Y: 0.9},
+		{X: 0.5, Y: 0}, {X: 0.5, Y: 0}, {X: 0.5, Y: 0}, {X: 0.5, Y: 0},
+	}
+
+	functions := PowerImageFunctions(data, 2)
+	base := functions[0].Calculate(PointsData{Points: points})
+	assert.True(t, base > 0 && base < 1)
+
+	// Mutating a weight incrementally gives the same fitness as calculating it from scratch
+	mutated := points.Copy()
+	mutated[5].X = 1
+	functions[1].Calculate(PointsData{Points: points})
+	functions[1].SetBase(functions[0])
+	incremental := functions[1].Calculate(PointsData{
+		Points:    mutated,
+		Mutations: []mutation.Mutation{{Old: points[5], New: mutated[5], Index: 5}},
+	})
+
+	full := PowerImageFunctions(data, 1)[0].Calculate(PointsData{Points: mutated})
+	assert.InDelta(t, incremental, full, 1e-9)
+	assert.NotEqual(t, incremental, base)
+}
+
+func TestDecimate(t *testing.T) {
+	random.Seed(0)
+
+	// Two flat colors, so only the points near the edge between them matter
+	img := image.NewRGBA(image.Rect(0, 0, 60, 40))
+	for x := 0; x < 60; x++ {
+		for y := 0; y < 40; y++ {
+			if x < 30 {
+				img.Set(x, y, color.RGBA{R: 200, G: 50, B: 50, A: math.MaxUint8})
+			} else {
+				img.Set(x, y, color.RGBA{R: 20, G: 60, B: 180, A: math.MaxUint8})
+			}
+		}
+	}
+	data := image2.ToData(img)
+
+	points := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0.5, Y: 0}, {X: 0.5, Y: 1}}
+	for i := 0; i < 40; i++ {
+		points = append(points, normgeom.NormPoint{X: float64(random.Float32()), Y: float64(random.Float32())})
+	}
+
+	threshold := 1e-4
+	decimated, pins := Decimate(points, nil, data, threshold)
+	assert.Equal(t, len(pins), len(decimated))
+
+	before := NewTrianglesImageFunction(data).Calculate(PointsData{Points: points})
+	after := NewTrianglesImageFunction(data).Calculate(PointsData{Points: decimated})
+
+	assert.True(t, len(decimated) < len(points)/2)
+	assert.True(t, after >= before-float64(len(points)-len(decimated))*threshold)
+
+	// The corners are kept, as removing them leaves parts of the image blank
+	assert.Equal(t, decimated[:4], points[:4])
+
+	// Pinned points are kept, and duplicates are removed even with a tiny threshold
+	points = append(points, points[0], points[0])
+	decimated, pins = Decimate(points, normgeom.NewPins(len(points), 6, 7), data, 1e-12)
+
+	var pinned normgeom.NormPointGroup
+	for _, i := range pins.Indexes() {
+		pinned = append(pinned, decimated[i])
+	}
+	assert.Equal(t, pinned, points[6:8])
+
+	copies := 0
+	for _, p := range decimated {
+		if p == points[0] {
+			copies++
+		}
+	}
+	assert.Equal(t, copies, 1)
 }
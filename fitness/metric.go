@@ -0,0 +1,348 @@
+package fitness
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"math"
+)
+
+// A Metric measures the error of filling a shape with a single color, compared to the pixels
+// of the target image it covers. The error of all the shapes is summed to calculate the fitness.
+// All errors use RGB values between 0 and 255. Only the first Shape.Channels() channels of colors are used,
+// and the others should be 0.
+type Metric interface {
+	// Fill returns the color which minimizes the error of a shape.
+	Fill(shape *Shape) color.RGB
+
+	// Error returns the error of a shape when it's filled with a color.
+	Error(shape *Shape, fill color.RGB) float64
+
+	// Penalty returns the additional error of each pixel when a shape is filled with a color
+	// other than the one returned by Fill. The penalty is exact for L2, and an upper bound for the other metrics.
+	Penalty(fill, other color.RGB) float64
+
+	// MaxPixelError returns the maximum error a single channel of a pixel can have.
+	MaxPixelError() float64
+}
+
+// Histogram stores the number of pixels of a shape with each value (0 to 255) for the R, G and B channels.
+// Grayscale shapes only use the first channel.
+type Histogram [3][256]int
+
+// shapeLine is a horizontal line of pixels from x0 to x1 (exclusive) in row y.
+type shapeLine struct {
+	x0, x1, y int
+}
+
+// Shape represents the pixels of the target image covered by a shape, and is passed to a Metric.
+// The sums of the pixels are always available, while the histogram is only created when it's needed.
+type Shape struct {
+	pixels pixelData
+	lines  []shapeLine
+	sums   shapeSums
+
+	histogram      Histogram
+	histogramValid bool
+}
+
+// newShape returns an empty Shape which covers the pixels of an image.
+func newShape(pixels pixelData) Shape {
+	return Shape{pixels: pixels}
+}
+
+// reset removes all the pixels from the shape, so it can be reused.
+func (s *Shape) reset() {
+	s.lines = s.lines[:0]
+	s.sums = shapeSums{}
+	s.histogramValid = false
+}
+
+// addLine adds a horizontal line of pixels from x0 to x1 (exclusive) in row y to the shape.
+// Pixels outside the image are ignored.
+func (s *Shape) addLine(x0, x1, y int) {
+	x0, x1, ok := s.pixels.clamp(x0, x1, y)
+	if !ok {
+		return
+	}
+
+	s.lines = append(s.lines, shapeLine{x0, x1, y})
+	s.sums.addLine(s.pixels, x0, x1, y)
+	s.histogramValid = false
+}
+
+// Channels returns the number of channels of the shape's pixels: 3 for RGB, or 1 for grayscale
+// where the luminance of the pixels is stored in the R channel.
+func (s *Shape) Channels() int {
+	return s.pixels.channels
+}
+
+// Count returns the number of pixels in the shape.
+func (s *Shape) Count() int {
+	return s.sums.n
+}
+
+// Mean returns the average color of the pixels in the shape.
+func (s *Shape) Mean() color.RGB {
+	if s.sums.n == 0 {
+		return color.RGB{}
+	}
+
+	n := float64(s.sums.n) * 255
+	return color.RGB{R: float64(s.sums.r) / n, G: float64(s.sums.g) / n, B: float64(s.sums.b) / n}
+}
+
+// Variance returns the sum of the squared differences between each pixel and the average color.
+func (s *Shape) Variance() float64 {
+	return s.sums.variance()
+}
+
+// Histogram returns the histogram of the pixels in the shape.
+// It must not be modified, and is only valid until the shape changes.
+func (s *Shape) Histogram() *Histogram {
+	if !s.histogramValid {
+		s.histogram = Histogram{}
+
+		for _, l := range s.lines {
+			for x := l.x0; x < l.x1; x++ {
+				r, g, b := s.pixels.at(x, l.y)
+				s.histogram[0][r]++
+				if s.pixels.channels == 3 {
+					s.histogram[1][g]++
+					s.histogram[2][b]++
+				}
+			}
+		}
+
+		s.histogramValid = true
+	}
+
+	return &s.histogram
+}
+
+// channels returns the values of a color between 0 and 255.
+func channels(c color.RGB) [3]float64 {
+	return [3]float64{c.R * 255, c.G * 255, c.B * 255}
+}
+
+// fromChannels returns a color given its values between 0 and 255.
+func fromChannels(v [3]float64) color.RGB {
+	return color.RGB{R: v[0] / 255, G: v[1] / 255, B: v[2] / 255}
+}
+
+// histogramError returns the sum of a function of the differences between the
+// pixels of a shape and a color.
+func histogramError(s *Shape, fill color.RGB, f func(d float64) float64) float64 {
+	h := s.Histogram()
+	c := channels(fill)
+	e := 0.
+
+	for ch := 0; ch < s.Channels(); ch++ {
+		for v, n := range h[ch] {
+			if n > 0 {
+				e += float64(n) * f(math.Abs(float64(v)-c[ch]))
+			}
+		}
+	}
+
+	return e
+}
+
+// median returns the median value of one channel of a histogram.
+func median(h *[256]int, n int) float64 {
+	count := 0
+	for v, c := range h {
+		count += c
+		if 2*count >= n {
+			return float64(v)
+		}
+	}
+	return 0
+}
+
+type l2Metric struct{}
+
+// L2Metric returns a Metric which sums the squared differences of pixels (the variance),
+// and fills shapes with their average color. It's the default Metric.
+func L2Metric() Metric {
+	return l2Metric{}
+}
+
+func (l2Metric) Fill(s *Shape) color.RGB {
+	return s.Mean()
+}
+
+func (m l2Metric) Error(s *Shape, fill color.RGB) float64 {
+	return s.Variance() + float64(s.Count())*m.Penalty(s.Mean(), fill)
+}
+
+func (l2Metric) Penalty(fill, other color.RGB) float64 {
+	// The error of coloring a shape with a color other than its average is its variance plus the
+	// squared distance from the average for every pixel
+	return color.DistSq(fill, other) * 255 * 255
+}
+
+func (l2Metric) MaxPixelError() float64 {
+	return maxChannelDifference
+}
+
+type l1Metric struct{}
+
+// L1Metric returns a Metric which sums the absolute differences of pixels, and fills shapes with
+// the median of each channel. It's less affected by small areas of very different colors than L2Metric.
+func L1Metric() Metric {
+	return l1Metric{}
+}
+
+func (l1Metric) Fill(s *Shape) color.RGB {
+	h := s.Histogram()
+	n := s.Count()
+
+	var c [3]float64
+	for ch := 0; ch < s.Channels(); ch++ {
+		c[ch] = median(&h[ch], n)
+	}
+
+	return fromChannels(c)
+}
+
+func (l1Metric) Error(s *Shape, fill color.RGB) float64 {
+	return histogramError(s, fill, func(d float64) float64 {
+		return d
+	})
+}
+
+func (l1Metric) Penalty(fill, other color.RGB) float64 {
+	return (math.Abs(fill.R-other.R) + math.Abs(fill.G-other.G) + math.Abs(fill.B-other.B)) * 255
+}
+
+func (l1Metric) MaxPixelError() float64 {
+	return 255
+}
+
+// huberIterations is the number of iterations used to find the color which minimizes the Huber loss.
+const huberIterations = 8
+
+type huberMetric struct {
+	delta float64 // The difference at which the loss changes from quadratic to linear, between 0 and 255.
+}
+
+// HuberMetric returns a Metric which uses the Huber loss: the squared difference for differences smaller
+// than delta, and the absolute difference for larger ones. This combines the smoothness of L2Metric with
+// the robustness of L1Metric. delta is between 0 and 1.
+func HuberMetric(delta float64) Metric {
+	if delta <= 0 {
+		panic("fitness: the delta of the Huber metric must be positive")
+	}
+	return huberMetric{delta: delta * 255}
+}
+
+// loss returns the Huber loss of a difference.
+func (m huberMetric) loss(d float64) float64 {
+	if d <= m.delta {
+		return 0.5 * d * d
+	}
+	return m.delta * (d - 0.5*m.delta)
+}
+
+func (m huberMetric) Fill(s *Shape) color.RGB {
+	h := s.Histogram()
+	n := s.Count()
+
+	var c [3]float64
+
+	for ch := 0; ch < s.Channels(); ch++ {
+		// Start from the median and use iteratively reweighted least squares, where pixels
+		// further away than delta have a smaller weight
+		c[ch] = median(&h[ch], n)
+
+		for it := 0; it < huberIterations; it++ {
+			sum, total := 0., 0.
+			for v, count := range h[ch] {
+				if count == 0 {
+					continue
+				}
+				w := 1.
+				if d := math.Abs(float64(v) - c[ch]); d > m.delta {
+					w = m.delta / d
+				}
+				sum += w * float64(count) * float64(v)
+				total += w * float64(count)
+			}
+			if total > 0 {
+				c[ch] = sum / total
+			}
+		}
+	}
+
+	return fromChannels(c)
+}
+
+func (m huberMetric) Error(s *Shape, fill color.RGB) float64 {
+	return histogramError(s, fill, m.loss)
+}
+
+func (m huberMetric) Penalty(fill, other color.RGB) float64 {
+	// The Huber loss changes by at most delta for each unit of difference
+	return m.delta * (math.Abs(fill.R-other.R) + math.Abs(fill.G-other.G) + math.Abs(fill.B-other.B)) * 255
+}
+
+func (m huberMetric) MaxPixelError() float64 {
+	return m.loss(255)
+}
+
+type maxMetric struct{}
+
+// MaxMetric returns a Metric which uses the largest absolute difference of any pixel in a shape, for each channel,
+// multiplied by the number of pixels. Shapes are filled with the middle of the range of each channel,
+// so the worst pixel of every shape is made as close as possible to the target image.
+func MaxMetric() Metric {
+	return maxMetric{}
+}
+
+// bounds returns the smallest and largest value of one channel of a histogram.
+func bounds(h *[256]int) (float64, float64) {
+	lo, hi := 0, 255
+	for lo < hi && h[lo] == 0 {
+		lo++
+	}
+	for hi > lo && h[hi] == 0 {
+		hi--
+	}
+	return float64(lo), float64(hi)
+}
+
+func (maxMetric) Fill(s *Shape) color.RGB {
+	h := s.Histogram()
+
+	var c [3]float64
+	for ch := 0; ch < s.Channels(); ch++ {
+		lo, hi := bounds(&h[ch])
+		c[ch] = (lo + hi) / 2
+	}
+
+	return fromChannels(c)
+}
+
+func (maxMetric) Error(s *Shape, fill color.RGB) float64 {
+	if s.Count() == 0 {
+		return 0
+	}
+
+	h := s.Histogram()
+	c := channels(fill)
+	e := 0.
+
+	for ch := 0; ch < s.Channels(); ch++ {
+		lo, hi := bounds(&h[ch])
+		e += math.Max(math.Abs(lo-c[ch]), math.Abs(hi-c[ch]))
+	}
+
+	return e * float64(s.Count())
+}
+
+func (maxMetric) Penalty(fill, other color.RGB) float64 {
+	return (math.Abs(fill.R-other.R) + math.Abs(fill.G-other.G) + math.Abs(fill.B-other.B)) * 255
+}
+
+func (maxMetric) MaxPixelError() float64 {
+	return 255
+}
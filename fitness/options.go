@@ -0,0 +1,134 @@
+package fitness
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+)
+
+// An Option configures the fitness functions created by a constructor.
+type Option func(o *options)
+
+// options stores the configuration shared by the fitness functions of a constructor.
+type options struct {
+	metric Metric // Measures the error of each shape.
+
+	palette color.Palette // If not nil, shapes can only be colored with a color from the palette.
+
+	regularization Regularization // Penalties for poorly shaped triangles.
+
+	grayscale bool // If only the luminance of the target image is used.
+
+	constraints []normgeom.NormLine // Segments which are always edges of the triangulation.
+}
+
+// newOptions returns the options after applying a group of Option's.
+func newOptions(opts []Option) *options {
+	o := &options{metric: L2Metric()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithPalette makes fitness functions calculate the error of each shape after its color is replaced with
+// the closest color of a palette, instead of the average color of its pixels.
+// The colors of the palette can be changed in between calculations (for example by an algorithm optimizing
+// the palette), but its length can't. WithPalette panics if the palette is empty.
+func WithPalette(palette color.Palette) Option {
+	if len(palette) == 0 {
+		panic("the palette must contain at least one color")
+	}
+
+	return func(o *options) {
+		o.palette = palette
+	}
+}
+
+// WithMetric makes fitness functions measure the error of each shape using a Metric, instead of L2Metric.
+func WithMetric(metric Metric) Option {
+	return func(o *options) {
+		o.metric = metric
+	}
+}
+
+// WithGrayscale makes fitness functions only use the luminance of the target image, so shapes are
+// optimized to be rendered in shades of gray (or any other color.ToneMap).
+// With a palette, the error of each shape is calculated using the luminance of the palette's colors.
+func WithGrayscale() Option {
+	return func(o *options) {
+		o.grayscale = true
+	}
+}
+
+// WithConstraints makes triangle fitness functions triangulate points with a constrained Delaunay
+// triangulation, where each segment is always an edge of the triangles (for example the outline of an
+// object or the horizon). The constraints are kept when the triangulation is mutated.
+// The constructors panic if two constraints cross or a constraint's endpoints are the same pixel.
+func WithConstraints(constraints []normgeom.NormLine) Option {
+	return func(o *options) {
+		o.constraints = constraints
+	}
+}
+
+// newTriangulation returns an empty triangulation of an image with the constraints added to it.
+func (o *options) newTriangulation(w, h int) *incrdelaunay.Delaunay {
+	triangulation := incrdelaunay.NewDelaunay(w, h)
+	for _, c := range o.constraints {
+		err := triangulation.AddConstraint(createPoint(c.A.X, c.A.Y, w, h), createPoint(c.B.X, c.B.Y, w, h))
+		if err != nil {
+			panic(err)
+		}
+	}
+	return triangulation
+}
+
+// pixelData creates the pixelData of a target image.
+func (o *options) pixelData(target image.Data) pixelData {
+	if o.grayscale {
+		return fromImageGray(target)
+	}
+	return fromImage(target)
+}
+
+// maxPixelError returns the maximum error a single pixel can have.
+func (o *options) maxPixelError() float64 {
+	if o.grayscale {
+		return o.metric.MaxPixelError()
+	}
+	return o.metric.MaxPixelError() * 3
+}
+
+// shapeData calculates the data of a shape which is stored in the cache.
+func (o *options) shapeData(shape *Shape) shapeData {
+	fill := o.metric.Fill(shape)
+
+	return shapeData{
+		diff: o.metric.Error(shape, fill),
+		r:    float32(fill.R),
+		g:    float32(fill.G),
+		b:    float32(fill.B),
+		n:    int32(shape.Count()),
+	}
+}
+
+// shapeError returns the error of a shape given data about its pixels.
+func (o *options) shapeError(data shapeData) float64 {
+	if o.palette == nil || data.n == 0 {
+		return data.diff
+	}
+
+	fill := color.NewRGB(float64(data.r), float64(data.g), float64(data.b))
+
+	var nearest color.RGB
+	if o.grayscale {
+		// The luminance of grayscale shapes is stored in the R channel
+		nearest = color.RGB{R: color.Luminance(o.palette[o.palette.Match(color.Gray(fill.R), true)])}
+	} else {
+		nearest = o.palette[o.palette.Match(fill, false)]
+	}
+
+	// The penalty is calculated here instead of when the shape is cached, since the palette may have changed
+	return data.diff + float64(data.n)*o.metric.Penalty(fill, nearest)
+}
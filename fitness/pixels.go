@@ -1,13 +1,20 @@
 package fitness
 
 import (
+	"github.com/RH12503/Triangula/color"
 	"github.com/RH12503/Triangula/image"
 )
 
-// pixelData stores data relating to the pixels of an image, and is used in trianglesImageFunction.
+// pixelData stores prefix sums of the RGB values of each row of an image, as well as the sum of their squares.
+// The sums of any horizontal line of pixels can be found in constant time, so the variance of a shape
+// can be calculated exactly by rasterizing it into lines.
 type pixelData struct {
-	pixels        [][]pixel
+	rows          [][]pixelSum // rows[y][x] is the sum of the first x pixels of row y.
 	width, height int
+
+	// The number of channels of each pixel: 3 for RGB, or 1 for grayscale images, which store
+	// the luminance of each pixel in the R channel.
+	channels int
 }
 
 // Size returns the width and height of an pixelData.
@@ -15,19 +22,20 @@ func (p pixelData) Size() (int, int) {
 	return p.width, p.height
 }
 
-// pixel stores RGB values of a pixel as well as the sum of their squares. These values are used in trianglesImageFunction.
-type pixel struct {
-	r, g, b uint8
-	sq      uint32
+// pixelSum stores the sums of RGB values of pixels as well as the sum of their squares.
+// These values are used in trianglesImageFunction.
+type pixelSum struct {
+	r, g, b uint32
+	sq      uint64
 }
 
 // newPixelData creates a new pixelData given a width and height.
 func newPixelData(w, h int) pixelData {
-	data := pixelData{width: w, height: h}
-	data.pixels = make([][]pixel, h)
+	data := pixelData{width: w, height: h, channels: 3}
+	data.rows = make([][]pixelSum, h)
 
-	for i := range data.pixels {
-		data.pixels[i] = make([]pixel, w)
+	for i := range data.rows {
+		data.rows[i] = make([]pixelSum, w+1)
 	}
 
 	return data
@@ -38,65 +46,119 @@ func fromImage(image image.Data) pixelData {
 	w, h := image.Size()
 	data := newPixelData(w, h)
 
-	for y := range data.pixels {
-		for x := range data.pixels[y] {
-			col := &data.pixels[y][x]
+	for y, row := range data.rows {
+		for x := 0; x < w; x++ {
 			rgb := image.RGBAt(x, y)
 
-			col.r = uint8(rgb.R * 255)
-			col.g = uint8(rgb.G * 255)
-			col.b = uint8(rgb.B * 255)
-			col.sq = uint32(rgb.R*255*rgb.R*255 + rgb.G*255*rgb.G*255 + rgb.B*255*rgb.B*255)
+			r := uint32(rgb.R * 255)
+			g := uint32(rgb.G * 255)
+			b := uint32(rgb.B * 255)
+
+			sum := row[x]
+			sum.r += r
+			sum.g += g
+			sum.b += b
+			sum.sq += uint64(r*r + g*g + b*b)
+			row[x+1] = sum
 		}
 	}
 
 	return data
 }
 
-// pixelDataN stores the sum of RGB values of pixels in a N*N block.
-// This speeds up performance as the variation can be calculated in blocks instead of individual pixels.
-type pixelDataN struct {
-	pixels [][]pixelN
-}
+// fromImageGray creates a grayscale pixelData from the luminance of an image.Data.
+func fromImageGray(image image.Data) pixelData {
+	w, h := image.Size()
+	data := newPixelData(w, h)
+	data.channels = 1
+
+	for y, row := range data.rows {
+		for x := 0; x < w; x++ {
+			l := uint32(color.Luminance(image.RGBAt(x, y))*255 + 0.5)
 
-// pixel stores RGB values of a pixel as well as the sum of their squares in a N*N block.
-type pixelN struct {
-	r, g, b uint16
-	sq      uint32
+			sum := row[x]
+			sum.r += l
+			sum.sq += uint64(l * l)
+			row[x+1] = sum
+		}
+	}
+
+	return data
 }
 
-// newPixelDataN creates a new pixelDataN given a width and height.
-func newPixelDataN(w, h, n int) pixelDataN {
-	data := pixelDataN{}
-	data.pixels = make([][]pixelN, h-n+1)
+// clamp clamps a horizontal line of pixels from x0 to x1 (exclusive) in row y to the image,
+// returning false if no pixels of the line are inside the image.
+func (p pixelData) clamp(x0, x1, y int) (int, int, bool) {
+	if y < 0 || y >= p.height {
+		return 0, 0, false
+	}
 
-	for i := range data.pixels {
-		data.pixels[i] = make([]pixelN, w-n+1)
+	if x0 < 0 {
+		x0 = 0
+	}
+	if x1 > p.width {
+		x1 = p.width
 	}
 
-	return data
+	return x0, x1, x1 > x0
 }
 
-// fromImageN creates a pixelDataN from an image.Data with a block size n.
-func fromImageN(image image.Data, n int) pixelDataN {
-	w, h := image.Size()
-	data := newPixelDataN(w, h, n)
-
-	for y := range data.pixels {
-		for x := range data.pixels[y] {
-			col := &data.pixels[y][x]
-			// Loop through an n*n block and add the values
-			for i := 0; i < n; i++ {
-				for j := 0; j < n; j++ {
-					rgb := image.RGBAt(x+i, y+j)
-					col.r += uint16(rgb.R * 255)
-					col.g += uint16(rgb.G * 255)
-					col.b += uint16(rgb.B * 255)
-					col.sq += uint32(rgb.R*255*rgb.R*255 + rgb.G*255*rgb.G*255 + rgb.B*255*rgb.B*255)
-				}
-			}
-		}
+// line returns the sums of the pixels from x0 to x1 (exclusive) in row y, and the number of pixels.
+// Pixels outside the image are ignored.
+func (p pixelData) line(x0, x1, y int) (pixelSum, int) {
+	x0, x1, ok := p.clamp(x0, x1, y)
+	if !ok {
+		return pixelSum{}, 0
 	}
 
-	return data
+	row := p.rows[y]
+	a, b := row[x0], row[x1]
+
+	return pixelSum{
+		r:  b.r - a.r,
+		g:  b.g - a.g,
+		b:  b.b - a.b,
+		sq: b.sq - a.sq,
+	}, x1 - x0
+}
+
+// at returns the RGB values of a pixel, between 0 and 255.
+func (p pixelData) at(x, y int) (uint8, uint8, uint8) {
+	row := p.rows[y]
+	a, b := row[x], row[x+1]
+
+	return uint8(b.r - a.r), uint8(b.g - a.g), uint8(b.b - a.b)
+}
+
+// shapeSums accumulates the sums of the pixels covered by a shape, which are used to calculate its variance.
+type shapeSums struct {
+	r, g, b int
+	sq      int
+	n       int // The number of pixels.
+}
+
+// addLine adds a horizontal line of pixels to the sums.
+func (s *shapeSums) addLine(pixels pixelData, x0, x1, y int) {
+	sum, n := pixels.line(x0, x1, y)
+	s.r += int(sum.r)
+	s.g += int(sum.g)
+	s.b += int(sum.b)
+	s.sq += int(sum.sq)
+	s.n += n
+}
+
+// variance returns the sum of the squared differences between each pixel and the average color of the shape.
+func (s shapeSums) variance() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	r, g, b := float64(s.r), float64(s.g), float64(s.b)
+	return float64(s.sq) - (r*r+g*g+b*b)/float64(s.n)
+}
+
+// shapeData stores the statistics of the pixels of a shape which are needed to calculate its error.
+type shapeData struct {
+	diff    float64 // The error of the shape when filled with the color below.
+	r, g, b float32 // The color the shape is filled with, between 0 and 1.
+	n       int32   // The number of pixels.
 }
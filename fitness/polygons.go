@@ -7,18 +7,108 @@ import (
 	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
 )
 
-type polygonsImageFunction struct {
+// polygonScorer calculates the fitness of the polygons of a fitness function, caching the error of each polygon.
+// It's shared by fitness functions which create polygons in different ways.
+type polygonScorer struct {
 	target pixelData // pixels data of the target image.
 
-	// Variance data stored in blocks of pixels. The variance of a N*N block can easily be found instead of
-	// needing to iterate through N*N pixels.
-	targetN   pixelDataN
-	blockSize int // The size of each N*N block.
-
 	maxDifference float64 // The maximum difference of all pixels to the target image.
 
-	TriangleCache []CacheData
-	nextCache     []CacheData
+	options *options // The configuration shared by all the fitness functions of a constructor.
+
+	shape Shape // Reused to store the pixels of each shape which isn't cached.
+
+	cache     *Cache      // A cache storing polygons that have already had their variances calculated.
+	nextCache []CacheData // The variances calculated for each polygon.
+
+	stats CacheStats // Cache hits and misses since the last call to TakeStats.
+
+	polygon     geom.Polygon // For performance purposes.
+	polygonData []int32      // For performance purposes.
+}
+
+// newPolygonScorer returns a polygonScorer for a target image.
+func newPolygonScorer(pixels pixelData, options *options) polygonScorer {
+	w, h := pixels.Size()
+
+	return polygonScorer{
+		target:        pixels,
+		maxDifference: options.maxPixelError() * float64(w*h),
+		options:       options,
+		shape:         newShape(pixels),
+	}
+}
+
+// fitness returns the fitness of the polygons iterated by iterPolygons.
+func (g *polygonScorer) fitness(iterPolygons func(polygon func(points []incrdelaunay.FloatPoint))) float64 {
+	g.nextCache = g.nextCache[:0]
+
+	// Calculate the variance between the target image and current polygons
+
+	var difference float64
+
+	iterPolygons(func(points []incrdelaunay.FloatPoint) {
+
+		g.polygon.Points = g.polygon.Points[:0]
+		g.polygonData = g.polygonData[:0]
+
+		for _, p := range points {
+			new := geom.Point{
+				X: fastRound(p.X),
+				Y: fastRound(p.Y),
+			}
+			if len(g.polygon.Points) == 0 || g.polygon.Points[len(g.polygon.Points)-1] != new {
+				g.polygon.Points = append(g.polygon.Points, new)
+				g.polygonData = append(g.polygonData, int32(new.X))
+				g.polygonData = append(g.polygonData, int32(new.Y))
+			}
+		}
+
+		polyData := newPolygonCacheData(g.polygonData)
+
+		// Check if the polygon is in the cache
+		if data, ok := g.cache.Get(polyData); !ok {
+			g.stats.Misses++
+
+			// The polygon isn't in the cache, so calculate the error
+			g.shape.reset()
+			rasterize.DDAPolygonLines(g.polygon, g.shape.addLine)
+
+			polyData.shape = g.options.shapeData(&g.shape)
+			difference += g.options.shapeError(polyData.shape)
+			var newPolyData []int32
+			newPolyData = append(newPolyData, g.polygonData...)
+
+			polyData.coords = newPolyData
+
+			g.nextCache = append(g.nextCache, polyData)
+		} else {
+			// If the polygon is in the cache, we don't need to recalculate the error
+			g.stats.Hits++
+			difference += g.options.shapeError(data.(*PolygonCacheData).shape)
+			g.nextCache = append(g.nextCache, data)
+		}
+	})
+
+	return 1 - (difference / g.maxDifference)
+}
+
+func (g *polygonScorer) Cache() []CacheData {
+	return g.nextCache
+}
+
+func (g *polygonScorer) SetCache(cache *Cache) {
+	g.cache = cache
+}
+
+func (g *polygonScorer) TakeStats() CacheStats {
+	stats := g.stats
+	g.stats = CacheStats{}
+	return stats
+}
+
+type polygonsImageFunction struct {
+	polygonScorer
 
 	// The triangulation used to create the triangles.
 	Triangulation *incrdelaunay.IVoronoi
@@ -56,132 +146,25 @@ func (g *polygonsImageFunction) Calculate(data PointsData) float64 {
 	// Prepare for next generation
 	g.Base = nil
 
-	g.nextCache = g.nextCache[:0]
-
-	pixels := g.target.pixels
-	pixelsN := g.targetN.pixels
-
-	// Calcuate the variance between the target image and current triangles
-
-	var difference float64
-
-	cacheMask := uint64(len(g.TriangleCache)) - 1
-
-	tris := g.TriangleCache
-
-	var polygon geom.Polygon
-
-	var polygonData []int16
-
-	g.Triangulation.IterPolygons(func(points []incrdelaunay.FloatPoint) {
-
-		polygon.Points = polygon.Points[:0]
-		polygonData = polygonData[:0]
-
-		for _, p := range points {
-			new := geom.Point{
-				X: fastRound(p.X),
-				Y: fastRound(p.Y),
-			}
-			if len(polygon.Points) == 0 || polygon.Points[len(polygon.Points)-1] != new {
-				polygon.Points = append(polygon.Points, new)
-				polygonData = append(polygonData, int16(new.X))
-				polygonData = append(polygonData, int16(new.Y))
-			}
-		}
-
-		polyData := &PolygonCacheData{
-			coords: polygonData,
-		}
-
-		hash := polyData.Hash()
-
-		index0 := uint32(hash & cacheMask)
-
-		data := tris[index0]
-
-		// Check if the triangle is in the cache
-		if data == nil || !data.Equals(polyData) {
-			// The triangle isn't in the hash, so calculate the variance
-			// Welford's online algorithm is used:
-			// https://en.wikipedia.org/wiki/Algorithms_for_calculating_variance#Welford's_online_algorithm
-			n := 0
-			var sR0, sG0, sB0 int
-			var sSq int
-
-			rasterize.DDAPolygonBlocks(polygon, g.blockSize, func(x0, x1, y int) {
-				row := pixels[y]
-				if x0 >= 0 && x1 <= len(row) {
-					for x := x0; x < x1; x++ {
-						pixel := row[x]
-						sR0 += int(pixel.r)
-						sG0 += int(pixel.g)
-						sB0 += int(pixel.b)
-						sSq += int(pixel.sq)
-					}
-				}
-				n += x1 - x0
-			}, func(x, y int) {
-				pixel := pixelsN[y][x]
-				sR0 += int(pixel.r)
-				sG0 += int(pixel.g)
-				sB0 += int(pixel.b)
-				sSq += int(pixel.sq)
-				n += g.blockSize * g.blockSize
-			})
-			var diff float64
-			if n != 0 {
-				diff = float64(sSq) - float64(sR0*sR0+sG0*sG0+sB0*sB0)/float64(n)
-			}
-			difference += diff
-			polyData.fitness = diff
-			polyData.SetCachedHash(index0)
-			var newPolyData []int16
-			newPolyData = append(newPolyData, polygonData...)
-
-			polyData.coords = newPolyData
-
-			g.nextCache = append(g.nextCache, polyData)
-		} else {
-			// If the triangle is in the cache, we don't need to recalculate the variance
-			difference += data.Data()
-			g.nextCache = append(g.nextCache, data)
-		}
-	})
-
-	g.TriangleCache = g.nextCache
-
-	return 1 - (difference / g.maxDifference)
+	return g.fitness(g.Triangulation.IterPolygons)
 }
 
 func (g *polygonsImageFunction) SetBase(other CacheFunction) {
 	g.Base = other.(*polygonsImageFunction).Triangulation
 }
 
-func (g *polygonsImageFunction) Cache() []CacheData {
-	return g.TriangleCache
-}
-
-func (g *polygonsImageFunction) SetCache(cache []CacheData) {
-	g.TriangleCache = cache
-}
-
-func PolygonsImageFunctions(target image.Data, blockSize, n int) []CacheFunction {
-	w, h := target.Size()
+// PolygonsImageFunctions returns an array of fitness functions for polygons configured with a group of Option's.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func PolygonsImageFunctions(target image.Data, n int, opts ...Option) []CacheFunction {
+	checkSize(target)
 
 	functions := make([]CacheFunction, n)
-	pixels := fromImage(target)
-	pixelsN := fromImageN(target, blockSize)
-
-	maxDiff := float64(maxPixelDifference * w * h)
+	options := newOptions(opts)
+	pixels := options.pixelData(target)
 
 	for i := 0; i < n; i++ {
 		function := polygonsImageFunction{
-			target:        pixels,
-			targetN:       pixelsN,
-			blockSize:     blockSize,
-			maxDifference: maxDiff,
-			TriangleCache: make([]CacheData, 2),
+			polygonScorer: newPolygonScorer(pixels, options),
 		}
 		functions[i] = &function
 	}
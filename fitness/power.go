@@ -0,0 +1,74 @@
+package fitness
+
+import (
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+)
+
+type powerImageFunction struct {
+	polygonScorer
+
+	// The power diagram used to create the polygons.
+	Diagram *incrdelaunay.PowerDiagram
+	// The power diagram of the points before being mutated accessed from the
+	// fitness function's base.
+	Base *incrdelaunay.PowerDiagram
+}
+
+// Calculate returns the fitness of a group of points, where the first half are the sites of a power diagram
+// and the second half are their weights (see normgeom.WeightedSites).
+func (g *powerImageFunction) Calculate(data PointsData) float64 {
+	sites, weights := normgeom.WeightedSites(data.Points)
+
+	w, h := g.target.Size()
+	maxWeight := incrdelaunay.MaxPowerWeight(len(sites), w, h)
+
+	if g.Diagram == nil {
+		// If there's no base power diagram, the whole power diagram needs to be recalculated
+		g.Diagram = incrdelaunay.NewPowerDiagram(w, h, maxWeight)
+		for i, p := range sites {
+			g.Diagram.Update(i, createPoint(p.X, p.Y, w, h), weights[i]*maxWeight)
+		}
+	} else if g.Base != nil {
+		// If there is a base power diagram, set this power diagram to the base
+		g.Diagram.Set(g.Base)
+
+		// And then update the sites whose points or weights have been mutated
+		for _, m := range data.Mutations {
+			i := m.Index % len(sites)
+			p := sites[i]
+			g.Diagram.Update(i, createPoint(p.X, p.Y, w, h), weights[i]*maxWeight)
+		}
+	}
+
+	// Prepare for next generation
+	g.Base = nil
+
+	return g.fitness(g.Diagram.IterPolygons)
+}
+
+func (g *powerImageFunction) SetBase(other CacheFunction) {
+	g.Base = other.(*powerImageFunction).Diagram
+}
+
+// PowerImageFunctions returns an array of fitness functions for the cells of power diagrams configured with a
+// group of Option's. Point groups are created with generator.NewPowerGenerator, so each site has a weight which
+// is mutated alongside its position and lets its cell grow or shrink.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func PowerImageFunctions(target image.Data, n int, opts ...Option) []CacheFunction {
+	checkSize(target)
+
+	functions := make([]CacheFunction, n)
+	options := newOptions(opts)
+	pixels := options.pixelData(target)
+
+	for i := 0; i < n; i++ {
+		function := powerImageFunction{
+			polygonScorer: newPolygonScorer(pixels, options),
+		}
+		functions[i] = &function
+	}
+
+	return functions
+}
@@ -0,0 +1,96 @@
+package fitness
+
+import (
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"math"
+)
+
+// Regularization stores the weights of penalties which make triangle fitness functions prefer
+// triangles with a better shape, trading a little accuracy for cleaner geometry.
+//
+// The MinAngle and AspectRatio weights are the fraction of the maximum difference to the target image that
+// a triangulation is penalized by in the worst case. The AreaVariance weight multiplies the squared coefficient
+// of variation of the triangles' areas, which has no upper bound: it's 1 when the standard deviation of the areas
+// equals their mean. Small values such as 0.01 are typical, and a weight of 0 disables a penalty.
+type Regularization struct {
+	MinAngle     float64 // Penalizes triangles with small angles.
+	AspectRatio  float64 // Penalizes triangles which are long and thin.
+	AreaVariance float64 // Penalizes triangles which are very different in size to the rest (unbounded).
+}
+
+// WithRegularization adds penalties for poorly shaped triangles to triangle fitness functions.
+func WithRegularization(regularization Regularization) Option {
+	return func(o *options) {
+		o.regularization = regularization
+	}
+}
+
+// enabled returns if any of the penalties are used.
+func (r Regularization) enabled() bool {
+	return r.MinAngle != 0 || r.AspectRatio != 0 || r.AreaVariance != 0
+}
+
+// regularizer accumulates the quality of the triangles of a triangulation.
+type regularizer struct {
+	angle  float64 // The sum of how far each triangle's smallest angle is from 60 degrees, between 0 and 1.
+	aspect float64 // The sum of how far each triangle's aspect ratio is from an equilateral triangle's, between 0 and 1.
+
+	area, areaSq float64 // The sum of the areas and squared areas of the triangles.
+
+	n int // The number of triangles.
+}
+
+// add adds a triangle with a specified area.
+func (r *regularizer) add(a, b, c incrdelaunay.Point, area float64) {
+	r.n++
+	r.area += area
+	r.areaSq += area * area
+
+	// Squared lengths of the edges opposite each vertex
+	sqA := float64(b.DistSq(c))
+	sqB := float64(a.DistSq(c))
+	sqC := float64(a.DistSq(b))
+
+	shortest := math.Min(sqA, math.Min(sqB, sqC))
+	longest := math.Max(sqA, math.Max(sqB, sqC))
+
+	if shortest == 0 || area == 0 {
+		// A degenerate triangle is as bad as possible
+		r.angle++
+		r.aspect++
+		return
+	}
+
+	// The smallest angle is opposite the shortest edge, found using the law of cosines
+	others := sqA + sqB + sqC - shortest
+	other0 := longest
+	other1 := others - longest
+	cos := (other0 + other1 - shortest) / (2 * math.Sqrt(other0*other1))
+	angle := math.Acos(math.Max(-1, math.Min(1, cos)))
+
+	r.angle += math.Max(0, 1-angle/(math.Pi/3))
+
+	// The ratio of the shortest altitude to the longest edge, normalized so an equilateral triangle has a ratio of 1
+	ratio := 4 * area / (math.Sqrt(3) * longest)
+
+	r.aspect += math.Max(0, 1-ratio)
+}
+
+// penalty returns the total penalty as a fraction of the maximum difference, which can be more than the sum of
+// the weights because of the area variance.
+func (r regularizer) penalty(weights Regularization) float64 {
+	if r.n == 0 {
+		return 0
+	}
+
+	n := float64(r.n)
+	penalty := weights.MinAngle*r.angle/n + weights.AspectRatio*r.aspect/n
+
+	// The squared coefficient of variation of the areas
+	if mean := r.area / n; mean > 0 {
+		variance := r.areaSq/n - mean*mean
+		penalty += weights.AreaVariance * math.Max(0, variance) / (mean * mean)
+	}
+
+	return penalty
+}
@@ -15,19 +15,20 @@ import (
 type trianglesImageFunction struct {
 	target pixelData // pixels data of the target image.
 
-	// Variance data stored in blocks of pixels. The variance of a N*N block can easily be found instead of
-	// needing to iterate through N*N pixels.
-	targetN   pixelDataN
-	blockSize int // The size of each N*N block.
-
 	maxDifference float64 // The maximum difference of all pixels to the target image.
 
-	TriangleCache []CacheData // A cache storing triangles that have already had their variances calculated.
+	options *options // The configuration shared by all the fitness functions of a constructor.
+
+	shape Shape // Reused to store the pixels of each shape which isn't cached.
+
+	cache *Cache // A cache storing triangles that have already had their variances calculated.
 
 	// The variance calculated for each triangle are put here. This means if the triangles don't change
 	// in the next generation, they won't need to be reevaluated.
 	nextCache []CacheData
 
+	stats CacheStats // Cache hits and misses since the last call to TakeStats.
+
 	// The triangulation used to create the triangles.
 	Triangulation *incrdelaunay.Delaunay
 	// The triangulation of the points before being mutated accessed from the
@@ -43,7 +44,7 @@ func (t *trianglesImageFunction) Calculate(data PointsData) float64 {
 
 	if t.Triangulation == nil {
 		// If there's no base triangulation, the whole triangulation needs to be recalculated
-		t.Triangulation = incrdelaunay.NewDelaunay(w, h)
+		t.Triangulation = t.options.newTriangulation(w, h)
 		for _, p := range points {
 			t.Triangulation.Insert(createPoint(p.X, p.Y, w, h))
 		}
@@ -66,95 +67,62 @@ func (t *trianglesImageFunction) Calculate(data PointsData) float64 {
 
 	t.nextCache = t.nextCache[:0]
 
-	pixels := t.target.pixels
-	pixelsN := t.targetN.pixels
-
 	// Calcuate the variance between the target image and current triangles
 
 	var difference float64
 
-	cacheMask := uint64(len(t.TriangleCache)) - 1
-
-	tris := t.TriangleCache
-
 	area := 0.
 
+	regularize := t.options.regularization.enabled()
+	var regularizer regularizer
+
 	t.Triangulation.IterTriangles(func(triangle incrdelaunay.Triangle) {
 		a := triangle.A
 		b := triangle.B
 		c := triangle.C
 
 		// The total area is taken into account when calculating the fitness
-		area += math.Abs(0.5 * ((float64(b.X-a.X) * float64(c.Y-a.Y)) - (float64(c.X-a.X) * float64(b.Y-a.Y))))
-
-		triData := &TriangleCacheData{
-			aX: a.X,
-			aY: a.Y,
-			bX: b.X,
-			bY: b.Y,
-			cX: c.X,
-			cY: c.Y,
+		triArea := math.Abs(0.5 * ((float64(b.X-a.X) * float64(c.Y-a.Y)) - (float64(c.X-a.X) * float64(b.Y-a.Y))))
+		area += triArea
+
+		if regularize {
+			regularizer.add(a, b, c, triArea)
 		}
 
-		hash := triData.Hash()
+		triData := newTriangleCacheData(a.X, a.Y, b.X, b.Y, c.X, c.Y)
 
-		index0 := uint32(hash & cacheMask)
+		// Check if the triangle is in the cache
+		if data, ok := t.cache.Get(triData); !ok {
+			t.stats.Misses++
 
-		data := tris[index0]
+			// The triangle isn't in the cache, so calculate the error
+			tri := geom.NewTriangle(int(triData.aX), int(triData.aY), int(triData.bX), int(triData.bY),
+				int(triData.cX), int(triData.cY))
 
-		// Check if the triangle is in the cache
-		if data == nil || !data.Equals(triData) {
-			// The triangle isn't in the hash, so calculate the variance
-			// Welford's online algorithm is used:
-			// https://en.wikipedia.org/wiki/Algorithms_for_calculating_variance#Welford's_online_algorithm
-			n := 0
-			var sR0, sG0, sB0 int
-			var sSq int
-
-			tri := geom.NewTriangle(int(a.X), int(a.Y), int(b.X), int(b.Y), int(c.X), int(c.Y))
-
-			rasterize.DDATriangleBlocks(tri, t.blockSize, func(x0, x1, y int) {
-				row := pixels[y]
-				if x0 >= 0 && x1 <= len(row) {
-					for x := x0; x < x1; x++ {
-						pixel := row[x]
-						sR0 += int(pixel.r)
-						sG0 += int(pixel.g)
-						sB0 += int(pixel.b)
-						sSq += int(pixel.sq)
-					}
-				}
-				n += x1 - x0
-			}, func(x, y int) {
-				pixel := pixelsN[y][x]
-				sR0 += int(pixel.r)
-				sG0 += int(pixel.g)
-				sB0 += int(pixel.b)
-				sSq += int(pixel.sq)
-				n += t.blockSize * t.blockSize
-			})
-			var diff float64
-			if n != 0 {
-				diff = float64(sSq) - float64(sR0*sR0+sG0*sG0+sB0*sB0)/float64(n)
-			}
-			difference += diff
-			triData.fitness = diff
-			triData.SetCachedHash(index0)
+			t.shape.reset()
+			rasterize.DDATriangleLines(tri, t.shape.addLine)
+
+			triData.shape = t.options.shapeData(&t.shape)
+			difference += t.options.shapeError(triData.shape)
 			t.nextCache = append(t.nextCache, triData)
 		} else {
-			// If the triangle is in the cache, we don't need to recalculate the variance
-			difference += data.Data()
+			// If the triangle is in the cache, we don't need to recalculate the error
+			t.stats.Hits++
+			difference += t.options.shapeError(data.(*TriangleCacheData).shape)
 			t.nextCache = append(t.nextCache, data)
 		}
 	})
 
-	t.TriangleCache = t.nextCache
-
 	// Lower the fitness based on how many blank pixels there are (the smaller the area)
 	// (As the triangles should cover the entire image)
 	blank := float64(w*h) - area
 
-	difference += maxPixelDifference * blank
+	difference += t.options.maxPixelError() * blank
+
+	// Penalize poorly shaped triangles
+	if regularize {
+		difference += regularizer.penalty(t.options.regularization) * t.maxDifference
+	}
 
 	return 1 - (difference / t.maxDifference)
 }
@@ -164,30 +132,41 @@ func (t *trianglesImageFunction) SetBase(other CacheFunction) {
 }
 
 func (t *trianglesImageFunction) Cache() []CacheData {
-	return t.TriangleCache
+	return t.nextCache
+}
+
+func (t *trianglesImageFunction) SetCache(cache *Cache) {
+	t.cache = cache
 }
 
-func (t *trianglesImageFunction) SetCache(cache []CacheData) {
-	t.TriangleCache = cache
+func (t *trianglesImageFunction) TakeStats() CacheStats {
+	stats := t.stats
+	t.stats = CacheStats{}
+	return stats
 }
 
-// TrianglesImageFunctions returns an array of fitness functions.
-func TrianglesImageFunctions(target image.Data, blockSize, n int) []CacheFunction {
+// TrianglesImageFunctions returns an array of fitness functions configured with a group of Option's.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func TrianglesImageFunctions(target image.Data, n int, opts ...Option) []CacheFunction {
+	checkSize(target)
+
 	w, h := target.Size()
 
 	functions := make([]CacheFunction, n)
-	pixels := fromImage(target)
-	pixelsN := fromImageN(target, blockSize)
+	options := newOptions(opts)
+	pixels := options.pixelData(target)
+
+	// Invalid constraints panic here instead of during the first calculation
+	options.newTriangulation(w, h)
 
-	maxDiff := float64(maxPixelDifference * w * h)
+	maxDiff := options.maxPixelError() * float64(w*h)
 
 	for i := 0; i < n; i++ {
 		function := trianglesImageFunction{
 			target:        pixels,
-			targetN:       pixelsN,
-			blockSize:     blockSize,
 			maxDifference: maxDiff,
-			TriangleCache: make([]CacheData, 2),
+			options:       options,
+			shape:         newShape(pixels),
 		}
 		functions[i] = &function
 	}
@@ -195,15 +174,23 @@ func TrianglesImageFunctions(target image.Data, blockSize, n int) []CacheFunctio
 	return functions
 }
 
-// NewTrianglesImageFunction returns a new fitness function.
-func NewTrianglesImageFunction(target image.Data, blockSize int) CacheFunction {
+// NewTrianglesImageFunction returns a new fitness function configured with a group of Option's.
+// It panics if the target image is wider or taller than incrdelaunay.MaxSize.
+func NewTrianglesImageFunction(target image.Data, opts ...Option) CacheFunction {
+	checkSize(target)
+
 	w, h := target.Size()
 
+	options := newOptions(opts)
+	pixels := options.pixelData(target)
+
+	// Invalid constraints panic here instead of during the first calculation
+	options.newTriangulation(w, h)
+
 	return &trianglesImageFunction{
-		target:        fromImage(target),
-		targetN:       fromImageN(target, blockSize),
-		blockSize:     blockSize,
-		maxDifference: float64(maxPixelDifference * w * h),
-		TriangleCache: make([]CacheData, 2),
+		target:        pixels,
+		maxDifference: options.maxPixelError() * float64(w*h),
+		options:       options,
+		shape:         newShape(pixels),
 	}
 }
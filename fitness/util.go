@@ -1,11 +1,14 @@
 package fitness
 
-import "github.com/RH12503/Triangula/triangulation/incrdelaunay"
+import (
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+)
 
-// The maximum difference for each pixel
+// The maximum difference for each channel of a pixel
 // there can be when compared to the target image. Variance is calculated, so the
 // 255 needs to be squared.
-const maxPixelDifference = 255 * 255 * 3
+const maxChannelDifference = 255 * 255
 
 // fastRound is an optimized version of math.Round.
 func fastRound(n float64) int {
@@ -14,7 +17,15 @@ func fastRound(n float64) int {
 
 func createPoint(x, y float64, w, h int) incrdelaunay.Point {
 	return incrdelaunay.Point{
-		X: int16(fastRound(x * float64(w))),
-		Y: int16(fastRound(y * float64(h))),
+		X: int32(fastRound(x * float64(w))),
+		Y: int32(fastRound(y * float64(h))),
+	}
+}
+
+// checkSize panics if a target image is too large to be triangulated, so the error surfaces
+// when the fitness functions are created instead of during the first calculation.
+func checkSize(target image.Data) {
+	if err := incrdelaunay.CheckSize(target.Size()); err != nil {
+		panic(err)
 	}
 }
@@ -0,0 +1,68 @@
+package generator
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// borderGenerator wraps another Generator so the generated point groups start with points on the border of
+// the image, which guarantee the triangulation covers the whole image.
+type borderGenerator struct {
+	generator  Generator
+	edgePoints int // The number of points on the edges, excluding the corners.
+}
+
+// Generate returns the 4 corners, followed by the edge points spread evenly around the border, and then
+// points from the other Generator, for a total of n points.
+func (b borderGenerator) Generate(n int) normgeom.NormPointGroup {
+	points := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+
+	for edge := 0; edge < 4; edge++ {
+		// Each edge has an equal share of the points, with the first edges getting the remainder
+		m := b.edgePoints / 4
+		if edge < b.edgePoints%4 {
+			m++
+		}
+
+		for k := 0; k < m; k++ {
+			// The position along the edge, which is never on a corner
+			f := float64(k+1) / float64(m+1)
+
+			switch edge {
+			case 0:
+				points = append(points, normgeom.NormPoint{X: f, Y: 0})
+			case 1:
+				points = append(points, normgeom.NormPoint{X: 1, Y: f})
+			case 2:
+				points = append(points, normgeom.NormPoint{X: 1 - f, Y: 1})
+			default:
+				points = append(points, normgeom.NormPoint{X: 0, Y: 1 - f})
+			}
+		}
+	}
+
+	if n < len(points) {
+		return points[:n]
+	}
+
+	return append(points, b.generator.Generate(n-len(points))...)
+}
+
+// Pins returns the Pins of the corners.
+func (b borderGenerator) Pins() normgeom.Pins {
+	return normgeom.NewPins(4, 0, 1, 2, 3)
+}
+
+// Border returns the Border of the edge points.
+func (b borderGenerator) Border() normgeom.Border {
+	border := make(normgeom.Border, 4+b.edgePoints)
+	for i := 4; i < len(border); i++ {
+		border[i] = true
+	}
+	return border
+}
+
+// NewBorderGenerator returns a Generator which adds the corners of the image and a number of points on its
+// edges to each point group, and fills the rest using another Generator.
+// The corners should be pinned with Pins, and the edge points should be mutated with mutation.NewBorderMethod
+// using Border so they can only slide along the edges.
+func NewBorderGenerator(generator Generator, edgePoints int) borderGenerator {
+	return borderGenerator{generator: generator, edgePoints: edgePoints}
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/normgeom"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// densitySampler picks random pixels within a rectangle of a density.Map, with a probability proportional
+// to their density.
+type densitySampler struct {
+	x0, y0, width int
+	cumulative    []float64 // The sum of the densities of each pixel and the pixels before it, by row.
+}
+
+// newDensitySampler returns a densitySampler for a whole map.
+func newDensitySampler(m density.Map) densitySampler {
+	w, h := m.Size()
+	return newRegionSampler(m, 0, 0, w, h)
+}
+
+// newRegionSampler returns a densitySampler for the pixels from (x0, y0) to (x1, y1) (exclusive) of a map.
+func newRegionSampler(m density.Map, x0, y0, x1, y1 int) densitySampler {
+	s := densitySampler{x0: x0, y0: y0, width: x1 - x0, cumulative: make([]float64, (x1-x0)*(y1-y0))}
+	sum := 0.
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			sum += m.At(x, y)
+			s.cumulative[(y-y0)*s.width+x-x0] = sum
+		}
+	}
+
+	return s
+}
+
+// sample returns the coordinates of a random pixel. If every pixel has a density of 0, the pixels are equally likely.
+func (s densitySampler) sample() (int, int) {
+	total := s.cumulative[len(s.cumulative)-1]
+
+	var i int
+	if total > 0 {
+		i = sort.SearchFloat64s(s.cumulative, rand.Float64()*total)
+		if i >= len(s.cumulative) {
+			i = len(s.cumulative) - 1
+		}
+	} else {
+		i = rand.Intn(len(s.cumulative))
+	}
+
+	return s.x0 + i%s.width, s.y0 + i/s.width
+}
+
+// densityGenerator places points randomly with a probability proportional to a density.Map.
+type densityGenerator struct {
+	density density.Map
+	budgets []density.Budget
+}
+
+// Generate returns a point group with n points. The points of each budget are placed inside its rectangle,
+// and the other points anywhere in the image.
+func (d densityGenerator) Generate(n int) normgeom.NormPointGroup {
+	if density.TotalPoints(d.budgets) > n {
+		panic("the budgets have more points than the point group")
+	}
+
+	w, h := d.density.Size()
+	points := normgeom.NormPointGroup{}
+
+	add := func(s densitySampler, count int, budget *density.Budget) {
+		for i := 0; i < count; i++ {
+			x, y := s.sample()
+			p := normgeom.NormPoint{
+				X: (float64(x) + rand.Float64()) / float64(w),
+				Y: (float64(y) + rand.Float64()) / float64(h),
+			}
+			if budget != nil {
+				budget.Constrain(&p)
+			}
+			points = append(points, p)
+		}
+	}
+
+	for _, b := range d.budgets {
+		b := b
+
+		// The pixels the rectangle overlaps
+		x0 := clampInt(int(math.Floor(b.Min.X*float64(w))), 0, w-1)
+		y0 := clampInt(int(math.Floor(b.Min.Y*float64(h))), 0, h-1)
+		x1 := clampInt(int(math.Ceil(b.Max.X*float64(w))), x0+1, w)
+		y1 := clampInt(int(math.Ceil(b.Max.Y*float64(h))), y0+1, h)
+
+		add(newRegionSampler(d.density, x0, y0, x1, y1), b.Points, &b)
+	}
+
+	add(newDensitySampler(d.density), n-len(points), nil)
+
+	return points
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// NewDensityGenerator returns a Generator which places points randomly with a probability proportional to
+// the density of a map, such as a density.FromImage map painted by an artist. Budgets optionally place
+// a number of the points inside rectangles of the image, and should also be passed to mutation.NewDensityMethod.
+func NewDensityGenerator(m density.Map, budgets ...density.Budget) densityGenerator {
+	return densityGenerator{density: m, budgets: budgets}
+}
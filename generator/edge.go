@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"math/rand"
+)
+
+// edgeGenerator places points along the edges of an image.
+type edgeGenerator struct {
+	edges density.Map // The strength of the edges of the image.
+	snap  int         // The radius points are snapped to the strongest edge within, or 0 to not snap.
+}
+
+// Generate returns a point group with n points, where each point is in a pixel picked with a probability
+// proportional to the strength of its edge.
+func (e edgeGenerator) Generate(n int) normgeom.NormPointGroup {
+	w, h := e.edges.Size()
+	sampler := newDensitySampler(e.edges)
+
+	points := normgeom.NormPointGroup{}
+
+	for i := 0; i < n; i++ {
+		x, y := sampler.sample()
+
+		if e.snap > 0 {
+			x, y = e.localMax(x, y)
+		}
+
+		points = append(points, normgeom.NormPoint{
+			X: (float64(x) + rand.Float64()) / float64(w),
+			Y: (float64(y) + rand.Float64()) / float64(h),
+		})
+	}
+
+	return points
+}
+
+// localMax returns the pixel with the strongest edge within the snapping radius of a pixel.
+func (e edgeGenerator) localMax(x, y int) (int, int) {
+	w, h := e.edges.Size()
+	bestX, bestY := x, y
+
+	for nY := y - e.snap; nY <= y+e.snap; nY++ {
+		for nX := x - e.snap; nX <= x+e.snap; nX++ {
+			if nX < 0 || nY < 0 || nX >= w || nY >= h {
+				continue
+			}
+			if e.edges.At(nX, nY) > e.edges.At(bestX, bestY) {
+				bestX, bestY = nX, nY
+			}
+		}
+	}
+
+	return bestX, bestY
+}
+
+// NewEdgeGenerator returns a Generator which places points along the edges of a target image, found using the
+// Sobel operator (see density.FromSobel), so the initial points already follow the contours of the image.
+// Pixels without an edge are picked with a probability of floor relative to the strongest edge, so some points
+// are spread across the whole image. If snapRadius is larger than 0, each point is moved to the strongest edge
+// within that many pixels.
+func NewEdgeGenerator(img image.Data, floor float64, snapRadius int) edgeGenerator {
+	return edgeGenerator{edges: density.FromSobel(img, floor), snap: snapRadius}
+}
@@ -1,7 +1,14 @@
 package generator
 
 import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
 	"github.com/stretchr/testify/assert"
+	stdimage "image"
+	"image/color"
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -16,3 +23,226 @@ func TestSpacedGenerator_Generate(t *testing.T) {
 	points := gen.Generate(121)
 	assert.Equal(t, len(points), 121)
 }
+
+func TestPinnedGenerator_Generate(t *testing.T) {
+	corners := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+	gen := NewPinnedGenerator(RandomGenerator{}, corners)
+
+	points := gen.Generate(20)
+	assert.Equal(t, len(points), 20)
+	assert.Equal(t, points[:4], corners)
+	assert.Equal(t, gen.Pins().Indexes(), []int{0, 1, 2, 3})
+
+	// Edge points are never on the corners, whatever the number of them
+	for _, edgePoints := range []int{1, 2, 6, 10, 14} {
+		points := NewBorderGenerator(RandomGenerator{}, edgePoints).Generate(4 + edgePoints)
+
+		unique := map[normgeom.NormPoint]bool{}
+		for _, p := range points {
+			assert.True(t, p.X == 0 || p.X == 1 || p.Y == 0 || p.Y == 1)
+			unique[p] = true
+		}
+		assert.Equal(t, len(unique), 4+edgePoints)
+	}
+}
+
+func TestBorderGenerator_Generate(t *testing.T) {
+	gen := NewBorderGenerator(RandomGenerator{}, 8)
+	points := gen.Generate(30)
+	assert.Equal(t, len(points), 30)
+
+	border := gen.Border()
+	for i, p := range points[:12] {
+		assert.True(t, p.X == 0 || p.X == 1 || p.Y == 0 || p.Y == 1)
+		assert.Equal(t, border.OnBorder(i), i >= 4)
+	}
+	assert.Equal(t, gen.Pins().Indexes(), []int{0, 1, 2, 3})
+}
+
+func TestPowerGenerator_Generate(t *testing.T) {
+	gen := NewPowerGenerator(RandomGenerator{})
+	points := gen.Generate(20)
+	assert.Equal(t, len(points), 20)
+
+	_, weights := normgeom.WeightedSites(points)
+	assert.Equal(t, weights[0], 0.5)
+}
+
+func TestLloydGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	// The closest pair of points should be further apart after relaxation
+	random := RandomGenerator{}.Generate(100)
+	rand.Seed(0)
+	relaxed := NewLloydGenerator(RandomGenerator{}, 10).Generate(100)
+
+	assert.Equal(t, len(relaxed), 100)
+	assert.True(t, minDist(relaxed) > 2*minDist(random))
+}
+
+func TestWeightedLloydGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	// The left half of the image is much denser
+	m := density.NewMap(50, 50)
+	for x := 25; x < 50; x++ {
+		for y := 0; y < 50; y++ {
+			m.Set(x, y, 0.05)
+		}
+	}
+
+	points := NewWeightedLloydGenerator(RandomGenerator{}, 50, m).Generate(100)
+
+	left := 0
+	for _, p := range points {
+		if p.X < 0.5 {
+			left++
+		}
+	}
+	assert.True(t, left > 58)
+}
+
+func TestEdgeGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	// An image with a vertical edge in the middle
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 40, 40))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 40; y++ {
+			if x >= 20 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	data := image.ToData(img)
+
+	points := NewEdgeGenerator(data, 0.01, 0).Generate(200)
+	assert.Equal(t, len(points), 200)
+
+	near := 0
+	for _, p := range points {
+		if math.Abs(p.X-0.5) < 0.1 {
+			near++
+		}
+	}
+	assert.True(t, near > 150)
+
+	// Snapped points are all on the edge, except those too far away from it
+	points = NewEdgeGenerator(data, 0.01, 40).Generate(100)
+	for _, p := range points {
+		assert.True(t, math.Abs(p.X-0.5) < 0.05)
+	}
+}
+
+func TestPoissonGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	for _, n := range []int{1, 10, 1000} {
+		points := NewPoissonGenerator().Generate(n)
+		assert.Equal(t, len(points), n)
+	}
+
+	points := NewPoissonGenerator().Generate(1000)
+	assert.True(t, minDist(points) > 0.5/math.Sqrt(1000))
+
+	// Large numbers of points are fast
+	assert.Equal(t, len(NewPoissonGenerator().Generate(50000)), 50000)
+
+	// Every point is spaced, whatever the seed
+	for seed := int64(0); seed < 20; seed++ {
+		rand.Seed(seed)
+		points := NewPoissonGenerator().Generate(200)
+
+		assert.Equal(t, len(points), 200)
+		assert.True(t, minDist(points) > 0.5/math.Sqrt(200))
+	}
+}
+
+func TestVariablePoissonGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	// The left half of the image is much denser
+	m := density.NewMap(100, 50)
+	for x := 50; x < 100; x++ {
+		for y := 0; y < 50; y++ {
+			m.Set(x, y, 0.1)
+		}
+	}
+
+	points := NewVariablePoissonGenerator(m).Generate(500)
+	assert.Equal(t, len(points), 500)
+
+	left := 0
+	for _, p := range points {
+		if p.X < 0.5 {
+			left++
+		}
+	}
+	assert.True(t, left > 400)
+}
+
+func TestDensityGenerator_Generate(t *testing.T) {
+	rand.Seed(0)
+
+	// Points can only be placed in the left half
+	m := density.NewMap(20, 20)
+	for x := 10; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			m.Set(x, y, 0)
+		}
+	}
+
+	budget := density.Budget{Min: normgeom.NormPoint{X: 0.8, Y: 0.8}, Max: normgeom.NormPoint{X: 1, Y: 1}, Points: 5}
+	points := NewDensityGenerator(m, budget).Generate(50)
+	assert.Equal(t, len(points), 50)
+
+	for i, p := range points {
+		if i < 5 {
+			assert.True(t, budget.Contains(p))
+		} else {
+			assert.True(t, p.X < 0.5)
+		}
+	}
+}
+
+func TestQuadtreeGenerator_Generate(t *testing.T) {
+	// A white square in the top left of a black image
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			if x >= 10 && x < 22 && y >= 10 && y < 22 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	gen := NewQuadtreeGenerator(image.ToData(img))
+
+	points := gen.Generate(60)
+	assert.Equal(t, len(points), 60)
+	assert.Equal(t, points, gen.Generate(60))
+	assert.Equal(t, points[:4], normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+
+	topLeft := 0
+	for _, p := range points {
+		if p.X <= 0.5 && p.Y <= 0.5 {
+			topLeft++
+		}
+	}
+	assert.True(t, topLeft > 40)
+
+	assert.Equal(t, len(gen.Generate(2)), 2)
+}
+
+func minDist(points normgeom.NormPointGroup) float64 {
+	min := math.Inf(1)
+	for i, a := range points {
+		for _, b := range points[i+1:] {
+			min = math.Min(min, normgeom.Dist(a, b))
+		}
+	}
+	return min
+}
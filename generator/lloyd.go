@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/geom"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/rasterize"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"math"
+)
+
+// lloydResolution is the size of the triangulation used to calculate the Voronoi cells.
+const lloydResolution = 4096
+
+// lloydGenerator spaces points using Lloyd relaxation, which repeatedly moves each point to the centroid of
+// its Voronoi cell, creating a centroidal Voronoi tessellation.
+type lloydGenerator struct {
+	generator  Generator // Creates the starting points.
+	iterations int
+
+	density *density.Map // If not nil, centroids are weighted by the density.
+}
+
+// Generate returns a point group with n points relaxed from the points of the other Generator.
+func (l lloydGenerator) Generate(n int) normgeom.NormPointGroup {
+	points := l.generator.Generate(n)
+
+	w, h := lloydResolution, lloydResolution
+	scale := 0.
+
+	if l.density != nil {
+		// Use the aspect ratio of the density map, with a resolution which is a multiple of its size
+		dW, dH := l.density.Size()
+		scale = math.Ceil(lloydResolution / math.Max(float64(dW), float64(dH)))
+		w, h = dW*int(scale), dH*int(scale)
+	}
+
+	sites := map[incrdelaunay.Point][]int{}
+
+	for it := 0; it < l.iterations; it++ {
+		triangulation := incrdelaunay.NewDelaunay(w, h)
+
+		for k := range sites {
+			delete(sites, k)
+		}
+
+		for i, p := range points {
+			site := incrdelaunay.Point{
+				X: int32(math.Round(p.X * float64(w))),
+				Y: int32(math.Round(p.Y * float64(h))),
+			}
+			sites[site] = append(sites[site], i)
+			triangulation.Insert(site)
+		}
+
+		incrdelaunay.VoronoiCells(triangulation, func(site incrdelaunay.Point, cell []incrdelaunay.FloatPoint) {
+			var x, y float64
+			var ok bool
+
+			if l.density == nil {
+				x, y, ok = centroid(cell)
+			} else {
+				x, y, ok = l.weightedCentroid(cell, scale)
+			}
+
+			if !ok {
+				return
+			}
+
+			// Duplicate points stay together
+			for _, i := range sites[site] {
+				points[i] = normgeom.NormPoint{X: x / float64(w), Y: y / float64(h)}
+				points[i].Constrain()
+			}
+		}, w, h)
+	}
+
+	return points
+}
+
+// centroid returns the centroid of a polygon, or false if its area is zero.
+func centroid(polygon []incrdelaunay.FloatPoint) (float64, float64, bool) {
+	var area, x, y float64
+
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		cross := a.X*b.Y - b.X*a.Y
+
+		area += cross
+		x += (a.X + b.X) * cross
+		y += (a.Y + b.Y) * cross
+	}
+
+	if area == 0 {
+		return 0, 0, false
+	}
+
+	return x / (3 * area), y / (3 * area), true
+}
+
+// weightedCentroid returns the centroid of a polygon weighted by the density of the pixels it covers, or false
+// if it doesn't cover any pixels with a density. scale is the size of a pixel of the density map.
+func (l lloydGenerator) weightedCentroid(polygon []incrdelaunay.FloatPoint, scale float64) (float64, float64, bool) {
+	dW, dH := l.density.Size()
+
+	var poly geom.Polygon
+	for _, p := range polygon {
+		poly.Points = append(poly.Points, geom.Point{
+			X: int(math.Round(p.X / scale)),
+			Y: int(math.Round(p.Y / scale)),
+		})
+	}
+
+	var total, x, y float64
+
+	rasterize.DDAPolygonLines(poly, func(x0, x1, row int) {
+		if row < 0 || row >= dH {
+			return
+		}
+		for col := maxInt(x0, 0); col < x1 && col < dW; col++ {
+			d := l.density.At(col, row)
+			total += d
+			x += d * (float64(col) + 0.5)
+			y += d * (float64(row) + 0.5)
+		}
+	})
+
+	if total == 0 {
+		return 0, 0, false
+	}
+
+	return x / total * scale, y / total * scale, true
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// NewLloydGenerator returns a Generator which evenly spaces the points of another Generator (such as a
+// RandomGenerator) using a number of iterations of Lloyd relaxation.
+func NewLloydGenerator(generator Generator, iterations int) lloydGenerator {
+	return lloydGenerator{generator: generator, iterations: iterations}
+}
+
+// NewWeightedLloydGenerator returns a Generator like NewLloydGenerator, except areas with a higher density
+// (such as a density.FromDetail map of the target image) end up with more points.
+// The points are spaced using the aspect ratio of the density map. Generate panics if the density map is
+// wider or taller than incrdelaunay.MaxSize.
+func NewWeightedLloydGenerator(generator Generator, iterations int, m density.Map) lloydGenerator {
+	return lloydGenerator{generator: generator, iterations: iterations, density: &m}
+}
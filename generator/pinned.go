@@ -0,0 +1,36 @@
+package generator
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// pinnedGenerator wraps another Generator so the generated point groups start with a set of pinned points.
+type pinnedGenerator struct {
+	generator Generator
+	pinned    normgeom.NormPointGroup
+}
+
+// Generate returns the pinned points followed by points from the other Generator, for a total of n points.
+func (p pinnedGenerator) Generate(n int) normgeom.NormPointGroup {
+	points := p.pinned.Copy()
+
+	if n > len(points) {
+		points = append(points, p.generator.Generate(n-len(points))...)
+	}
+
+	return points
+}
+
+// Pins returns the Pins of the point groups created by the generator.
+func (p pinnedGenerator) Pins() normgeom.Pins {
+	pins := make(normgeom.Pins, len(p.pinned))
+	for i := range pins {
+		pins[i] = true
+	}
+	return pins
+}
+
+// NewPinnedGenerator returns a Generator which places a set of pinned points (such as the corners of the image,
+// or the vertices of a logo) at the start of each point group, and fills the rest using another Generator.
+// Pins returns the matching normgeom.Pins, which can be passed to mutation.NewPinnedMethod.
+func NewPinnedGenerator(generator Generator, pinned normgeom.NormPointGroup) pinnedGenerator {
+	return pinnedGenerator{generator: generator, pinned: pinned.Copy()}
+}
@@ -0,0 +1,220 @@
+package generator
+
+import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/normgeom"
+	"math"
+	"math/rand"
+)
+
+const (
+	// poissonAttempts is the number of candidates tried around each point before it stops being active.
+	poissonAttempts = 30
+	// poissonPacking is roughly the number of points Poisson-disk sampling places in an area of radius².
+	poissonPacking = 0.7
+	// poissonTries is the number of times the radius is adjusted to get the right number of points, after which
+	// the radius is only shrunk until there are enough points.
+	poissonTries = 20
+	// poissonShrink is the most the radius is multiplied by when there aren't enough points.
+	poissonShrink = 0.95
+	// poissonTolerance is how many extra points (as a fraction) are allowed before the radius is adjusted.
+	// The extra points are then removed randomly.
+	poissonTolerance = 0.05
+	// poissonMinDensity is the lowest density relative to the highest density of the map, which limits
+	// the largest radius to 10 times the smallest radius.
+	poissonMinDensity = 0.01
+)
+
+// poissonGenerator places points using Bridson's algorithm for Poisson-disk sampling, so no two points are
+// closer than a radius while still looking random.
+type poissonGenerator struct {
+	density *density.Map // If not nil, the radius is smaller in areas with a higher density.
+}
+
+// poissonSampler stores the state of a single run of Bridson's algorithm.
+type poissonSampler struct {
+	density    *density.Map
+	maxDensity float64
+
+	w, h   float64 // The size of the area, where the longer side has a length of 1.
+	radius float64 // The radius in areas with the highest density.
+
+	cellSize   float64
+	cols, rows int
+	grid       []int32 // The index of the point in each cell, or -1. Each cell has at most one point.
+
+	points []normgeom.NormPoint
+}
+
+// Generate returns a point group with exactly n points spaced using Poisson-disk sampling.
+func (p poissonGenerator) Generate(n int) normgeom.NormPointGroup {
+	points := normgeom.NormPointGroup{}
+	if n <= 0 {
+		return points
+	}
+
+	s := poissonSampler{density: p.density, w: 1, h: 1}
+
+	// Use the aspect ratio of the density map, so points are evenly spaced in the image
+	area := 1.
+	if p.density != nil {
+		dW, dH := p.density.Size()
+		s.maxDensity = p.density.Max()
+
+		longest := math.Max(float64(dW), float64(dH))
+		s.w, s.h = float64(dW)/longest, float64(dH)/longest
+
+		// The area relative to the size of the radius
+		area = 0.
+		for y := 0; y < dH; y++ {
+			for x := 0; x < dW; x++ {
+				area += s.relativeDensity(p.density.At(x, y))
+			}
+		}
+		area *= s.w * s.h / float64(dW*dH)
+	}
+
+	s.radius = math.Sqrt(poissonPacking * area / float64(n))
+
+	for try := 0; ; try++ {
+		s.sample(n)
+
+		// After a number of tries, any radius which gives enough points is used
+		if len(s.points) >= n && (try >= poissonTries || float64(len(s.points)) <= float64(n)*(1+poissonTolerance)) {
+			break
+		}
+
+		// The number of points is inversely proportional to the radius squared
+		scale := math.Sqrt(float64(len(s.points)) / float64(n))
+		if len(s.points) < n && try >= poissonTries {
+			// Always shrink the radius by some amount, so there are eventually enough points
+			scale = math.Min(scale, poissonShrink)
+		}
+		s.radius *= scale
+	}
+
+	// Remove random points if there are too many, which leaves the other points spaced
+	for len(s.points) > n {
+		i := rand.Intn(len(s.points))
+		s.points[i] = s.points[len(s.points)-1]
+		s.points = s.points[:len(s.points)-1]
+	}
+
+	for _, q := range s.points {
+		points = append(points, normgeom.NormPoint{X: q.X / s.w, Y: q.Y / s.h})
+	}
+
+	return points
+}
+
+// relativeDensity returns a density relative to the highest density of the map.
+func (s *poissonSampler) relativeDensity(d float64) float64 {
+	if s.maxDensity <= 0 {
+		return 1
+	}
+	return math.Max(d/s.maxDensity, poissonMinDensity)
+}
+
+// radiusAt returns the radius around a point.
+func (s *poissonSampler) radiusAt(q normgeom.NormPoint) float64 {
+	if s.density == nil {
+		return s.radius
+	}
+
+	d := s.density.AtNorm(normgeom.NormPoint{X: q.X / s.w, Y: q.Y / s.h})
+	return s.radius / math.Sqrt(s.relativeDensity(d))
+}
+
+// sample runs Bridson's algorithm with the current radius. It stops early once there are far more than
+// n points, as the radius is too small.
+func (s *poissonSampler) sample(n int) {
+	// The smallest radius is the radius, so each cell can only contain one point
+	s.cellSize = s.radius / math.Sqrt2
+	s.cols = int(math.Ceil(s.w/s.cellSize)) + 1
+	s.rows = int(math.Ceil(s.h/s.cellSize)) + 1
+
+	s.grid = s.grid[:0]
+	for i := 0; i < s.cols*s.rows; i++ {
+		s.grid = append(s.grid, -1)
+	}
+
+	s.points = s.points[:0]
+	s.add(normgeom.NormPoint{X: rand.Float64() * s.w, Y: rand.Float64() * s.h})
+
+	active := []int32{0}
+
+	for len(active) > 0 && len(s.points) < 4*n {
+		a := rand.Intn(len(active))
+		p := s.points[active[a]]
+		r := s.radiusAt(p)
+
+		found := false
+
+		for i := 0; i < poissonAttempts; i++ {
+			// A random candidate between one and two radiuses away
+			angle := rand.Float64() * 2 * math.Pi
+			dist := r * (1 + rand.Float64())
+
+			q := normgeom.NormPoint{X: p.X + math.Cos(angle)*dist, Y: p.Y + math.Sin(angle)*dist}
+
+			if q.X < 0 || q.Y < 0 || q.X >= s.w || q.Y >= s.h || !s.fits(q) {
+				continue
+			}
+
+			active = append(active, int32(len(s.points)))
+			s.add(q)
+			found = true
+			break
+		}
+
+		if !found {
+			active[a] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+}
+
+// fits returns if a point isn't within its radius of any other point.
+func (s *poissonSampler) fits(q normgeom.NormPoint) bool {
+	r := s.radiusAt(q)
+	reach := int(math.Ceil(r / s.cellSize))
+
+	cX, cY := int(q.X/s.cellSize), int(q.Y/s.cellSize)
+
+	for y := cY - reach; y <= cY+reach; y++ {
+		if y < 0 || y >= s.rows {
+			continue
+		}
+		for x := cX - reach; x <= cX+reach; x++ {
+			if x < 0 || x >= s.cols {
+				continue
+			}
+			if i := s.grid[y*s.cols+x]; i != -1 && normgeom.Dist(s.points[i], q) < r {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// add adds a point to the grid.
+func (s *poissonSampler) add(q normgeom.NormPoint) {
+	cX, cY := int(q.X/s.cellSize), int(q.Y/s.cellSize)
+	s.grid[cY*s.cols+cX] = int32(len(s.points))
+	s.points = append(s.points, q)
+}
+
+// NewPoissonGenerator returns a Generator which evenly spaces points using Poisson-disk sampling with
+// a spatial grid, which is much faster than a spacedGenerator for large numbers of points.
+// The radius is chosen so exactly the number of points requested are generated.
+func NewPoissonGenerator() poissonGenerator {
+	return poissonGenerator{}
+}
+
+// NewVariablePoissonGenerator returns a Generator like NewPoissonGenerator, except the radius around each point
+// is smaller in areas with a higher density (such as a density.FromDetail map of the target image), so those
+// areas have more points. The points are spaced using the aspect ratio of the density map.
+func NewVariablePoissonGenerator(m density.Map) poissonGenerator {
+	return poissonGenerator{density: &m}
+}
@@ -0,0 +1,30 @@
+package generator
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// powerGenerator creates the point groups of power diagrams (see normgeom.WeightedSites).
+type powerGenerator struct {
+	generator Generator // Creates the sites.
+}
+
+// Generate returns a point group with n/2 sites from the other Generator followed by their n/2 weights.
+// The weights start at 0.5 so they can be mutated to grow or shrink the cells. n must be even.
+func (p powerGenerator) Generate(n int) normgeom.NormPointGroup {
+	if n%2 != 0 {
+		panic("the point group of a power diagram needs an even number of points")
+	}
+
+	points := p.generator.Generate(n / 2)
+
+	for i := 0; i < n/2; i++ {
+		points = append(points, normgeom.NormPoint{X: 0.5, Y: 0.5})
+	}
+
+	return points
+}
+
+// NewPowerGenerator returns a Generator which creates the sites of a power diagram using another Generator,
+// and gives each site a weight.
+func NewPowerGenerator(generator Generator) powerGenerator {
+	return powerGenerator{generator: generator}
+}
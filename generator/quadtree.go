@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"container/heap"
+	"github.com/RH12503/Triangula/image"
+	"github.com/RH12503/Triangula/normgeom"
+	"math/rand"
+)
+
+// quadtreeGenerator places points at the corners of the cells of a quadtree, which subdivides the cells
+// of an image with the most variance in color.
+type quadtreeGenerator struct {
+	width, height int
+
+	// Summed-area tables of the RGB values of the image, and of their squares.
+	// Each table has a row and column of zeros before the pixels.
+	sums, squares [3][]float64
+}
+
+// quadCell is a cell of a quadtree, from (x0, y0) to (x1, y1) in pixels.
+type quadCell struct {
+	x0, y0, x1, y1 int
+	variance       float64
+}
+
+// quadHeap is a priority queue of cells, with the cell with the most variance first.
+type quadHeap []quadCell
+
+func (h quadHeap) Len() int            { return len(h) }
+func (h quadHeap) Less(i, j int) bool  { return h[i].variance > h[j].variance }
+func (h quadHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *quadHeap) Push(x interface{}) { *h = append(*h, x.(quadCell)) }
+func (h *quadHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// Generate returns a point group with n points, starting with the corners of the image and repeatedly splitting
+// the cell with the most variance into four, adding the points at the corners of the new cells.
+// If the cells become too small to split, the remaining points are random.
+func (q quadtreeGenerator) Generate(n int) normgeom.NormPointGroup {
+	points := normgeom.NormPointGroup{}
+	added := map[[2]int]bool{}
+
+	add := func(x, y int) {
+		if len(points) >= n || added[[2]int{x, y}] {
+			return
+		}
+		added[[2]int{x, y}] = true
+		points = append(points, normgeom.NormPoint{X: float64(x) / float64(q.width), Y: float64(y) / float64(q.height)})
+	}
+
+	add(0, 0)
+	add(q.width, 0)
+	add(0, q.height)
+	add(q.width, q.height)
+
+	cells := &quadHeap{q.cell(0, 0, q.width, q.height)}
+
+	for len(points) < n && cells.Len() > 0 {
+		c := heap.Pop(cells).(quadCell)
+
+		// Cells smaller than two pixels can't be split
+		if c.x1-c.x0 < 2 || c.y1-c.y0 < 2 {
+			continue
+		}
+
+		mX, mY := (c.x0+c.x1)/2, (c.y0+c.y1)/2
+
+		add(mX, mY)
+		add(mX, c.y0)
+		add(mX, c.y1)
+		add(c.x0, mY)
+		add(c.x1, mY)
+
+		heap.Push(cells, q.cell(c.x0, c.y0, mX, mY))
+		heap.Push(cells, q.cell(mX, c.y0, c.x1, mY))
+		heap.Push(cells, q.cell(c.x0, mY, mX, c.y1))
+		heap.Push(cells, q.cell(mX, mY, c.x1, c.y1))
+	}
+
+	for len(points) < n {
+		points = append(points, normgeom.NormPoint{X: rand.Float64(), Y: rand.Float64()})
+	}
+
+	return points
+}
+
+// cell returns a quadCell and calculates its variance, which is the sum of the squared differences between
+// each pixel and the average color of the cell.
+func (q quadtreeGenerator) cell(x0, y0, x1, y1 int) quadCell {
+	c := quadCell{x0: x0, y0: y0, x1: x1, y1: y1}
+
+	n := float64((x1 - x0) * (y1 - y0))
+	if n == 0 {
+		return c
+	}
+
+	for ch := 0; ch < 3; ch++ {
+		sum := q.area(q.sums[ch], x0, y0, x1, y1)
+		squares := q.area(q.squares[ch], x0, y0, x1, y1)
+		c.variance += squares - sum*sum/n
+	}
+
+	return c
+}
+
+// area returns the sum of the values of a summed-area table from (x0, y0) to (x1, y1) (exclusive).
+func (q quadtreeGenerator) area(table []float64, x0, y0, x1, y1 int) float64 {
+	stride := q.width + 1
+	return table[y1*stride+x1] - table[y0*stride+x1] - table[y1*stride+x0] + table[y0*stride+x0]
+}
+
+// NewQuadtreeGenerator returns a Generator which subdivides an image into a quadtree based on the variance
+// in color of each cell, and places points at the corners of the cells. The points follow the structure of
+// the image and are deterministic, so they can also be rendered directly as an instant preview.
+func NewQuadtreeGenerator(img image.Data) quadtreeGenerator {
+	w, h := img.Size()
+	stride := w + 1
+
+	q := quadtreeGenerator{width: w, height: h}
+
+	for ch := 0; ch < 3; ch++ {
+		q.sums[ch] = make([]float64, stride*(h+1))
+		q.squares[ch] = make([]float64, stride*(h+1))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.RGBAt(x, y)
+			values := [3]float64{c.R, c.G, c.B}
+
+			for ch, v := range values {
+				i := (y+1)*stride + x + 1
+				q.sums[ch][i] = v + q.sums[ch][i-1] + q.sums[ch][i-stride] - q.sums[ch][i-stride-1]
+				q.squares[ch][i] = v*v + q.squares[ch][i-1] + q.squares[ch][i-stride] - q.squares[ch][i-stride-1]
+			}
+		}
+	}
+
+	return q
+}
@@ -15,13 +15,9 @@ type spacedGenerator struct {
 }
 
 func (s spacedGenerator) Generate(n int) normgeom.NormPointGroup {
+	// Corner points can be added by wrapping the generator with NewBorderGenerator
 	points := randomPoints(n)
 
-	/*points = append(points, normgeom.NormPoint{0, 0})
-	points = append(points, normgeom.NormPoint{0, 1})
-	points = append(points, normgeom.NormPoint{1, 1})
-	points = append(points, normgeom.NormPoint{1, 0})*/
-
 	temp := startTemp
 
 	for i := 0; i < s.iterations; i++ {
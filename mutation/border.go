@@ -0,0 +1,29 @@
+package mutation
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// borderMethod wraps another Method so points on the border only slide along the edge they're on.
+type borderMethod struct {
+	method Method
+	border normgeom.Border
+}
+
+func (b borderMethod) Mutate(points normgeom.NormPointGroup, mutated func(mutation Mutation)) {
+	b.method.Mutate(points, func(mutation Mutation) {
+		if b.border.OnBorder(mutation.Index) {
+			points[mutation.Index] = normgeom.Slide(mutation.Old, points[mutation.Index])
+			mutation.New = points[mutation.Index]
+
+			if mutation.New == mutation.Old {
+				return
+			}
+		}
+		mutated(mutation)
+	})
+}
+
+// NewBorderMethod returns a Method which mutates points using another Method, except points on the border
+// are moved back onto the edge of the image they're on, so the triangulation always covers the whole image.
+func NewBorderMethod(method Method, border normgeom.Border) Method {
+	return borderMethod{method: method, border: border}
+}
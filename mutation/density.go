@@ -0,0 +1,63 @@
+package mutation
+
+import (
+	"github.com/RH12503/Triangula/density"
+	"github.com/RH12503/Triangula/normgeom"
+	"math"
+)
+
+const (
+	// minDensityStep and maxDensityStep limit how much the steps of mutations are scaled.
+	minDensityStep = 0.25
+	maxDensityStep = 4
+)
+
+// densityMethod wraps another Method so mutations take smaller steps in areas with a higher density.
+type densityMethod struct {
+	method  Method
+	density density.Map
+	mean    float64 // The average density, where steps aren't scaled.
+	budgets []density.Budget
+}
+
+func (d densityMethod) Mutate(points normgeom.NormPointGroup, mutated func(mutation Mutation)) {
+	d.method.Mutate(points, func(mutation Mutation) {
+		scale := d.stepScale(mutation.Old)
+
+		p := normgeom.NormPoint{
+			X: mutation.Old.X + (points[mutation.Index].X-mutation.Old.X)*scale,
+			Y: mutation.Old.Y + (points[mutation.Index].Y-mutation.Old.Y)*scale,
+		}
+		p.Constrain()
+
+		if b, ok := density.BudgetOf(d.budgets, mutation.Index); ok {
+			b.Constrain(&p)
+		}
+
+		points[mutation.Index] = p
+		mutation.New = p
+
+		if mutation.New == mutation.Old {
+			return
+		}
+		mutated(mutation)
+	})
+}
+
+// stepScale returns how much the step of a mutation of a point is scaled. As the spacing of points is
+// proportional to the inverse square root of the density, so are the steps.
+func (d densityMethod) stepScale(p normgeom.NormPoint) float64 {
+	v := d.density.AtNorm(p)
+	if v <= 0 {
+		return maxDensityStep
+	}
+	return math.Max(minDensityStep, math.Min(math.Sqrt(d.mean/v), maxDensityStep))
+}
+
+// NewDensityMethod returns a Method which mutates points using another Method, except the mutations take smaller
+// steps in areas of a density map with a higher density (and larger steps in areas with a lower density), so
+// points in detailed areas are fine-tuned. The points of each budget (see generator.NewDensityGenerator)
+// are kept inside its rectangle.
+func NewDensityMethod(method Method, m density.Map, budgets ...density.Budget) Method {
+	return densityMethod{method: method, density: m, mean: m.Mean(), budgets: budgets}
+}
@@ -1,8 +1,10 @@
 package mutation
 
 import (
+	"github.com/RH12503/Triangula/density"
 	"github.com/RH12503/Triangula/normgeom"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
 )
 
@@ -67,3 +69,95 @@ func TestRandomMethod_Mutate(t *testing.T) {
 	assert.NotEqual(t, otherPoints, points)
 	assert.Equal(t, c, 3)
 }
+
+func TestPinnedMethod_Mutate(t *testing.T) {
+	points := normgeom.NormPointGroup{
+		{X: 0.23, Y: 0.12},
+		{X: 0.56, Y: 0.34},
+		{X: 0.34, Y: 0.12},
+	}
+	otherPoints := points.Copy()
+
+	method := NewPinnedMethod(NewGaussianMethod(1, 1), normgeom.NewPins(3, 0, 2))
+	var indexes []int
+	method.Mutate(otherPoints, func(mutation Mutation) {
+		indexes = append(indexes, mutation.Index)
+	})
+
+	assert.Equal(t, indexes, []int{1})
+	assert.Equal(t, otherPoints[0], points[0])
+	assert.Equal(t, otherPoints[2], points[2])
+	assert.NotEqual(t, otherPoints[1], points[1])
+}
+
+func TestBorderMethod_Mutate(t *testing.T) {
+	points := normgeom.NormPointGroup{
+		{X: 0, Y: 0.12},
+		{X: 0.56, Y: 1},
+		{X: 0.34, Y: 0.12},
+	}
+	otherPoints := points.Copy()
+
+	method := NewBorderMethod(NewGaussianMethod(1, 1), normgeom.Border{true, true})
+	method.Mutate(otherPoints, func(mutation Mutation) {
+		assert.Equal(t, mutation.New, otherPoints[mutation.Index])
+	})
+
+	assert.Equal(t, otherPoints[0].X, 0.)
+	assert.Equal(t, otherPoints[1].Y, 1.)
+	assert.NotEqual(t, otherPoints[2], points[2])
+}
+
+func TestPowerMethod_Mutate(t *testing.T) {
+	points := normgeom.NormPointGroup{
+		{X: 0.2, Y: 0.3},
+		{X: 0.6, Y: 0.7},
+		{X: 0.5, Y: 0.5},
+		{X: 0.5, Y: 0.5},
+	}
+	otherPoints := points.Copy()
+
+	method := NewPowerMethod(NewGaussianMethod(1, 1))
+	method.Mutate(otherPoints, func(mutation Mutation) {
+		assert.Equal(t, mutation.New, otherPoints[mutation.Index])
+	})
+
+	assert.NotEqual(t, otherPoints[0].Y, points[0].Y)
+	assert.Equal(t, otherPoints[2].Y, 0.5)
+	assert.Equal(t, otherPoints[3].Y, 0.5)
+	assert.NotEqual(t, otherPoints[3].X, 0.5)
+}
+
+// shiftMethod moves every point to the right by a fixed amount.
+type shiftMethod float64
+
+func (s shiftMethod) Mutate(points normgeom.NormPointGroup, mutated func(mutation Mutation)) {
+	for i := range points {
+		old := points[i]
+		points[i].X += float64(s)
+		mutated(Mutation{Old: old, New: points[i], Index: i})
+	}
+}
+
+func TestDensityMethod_Mutate(t *testing.T) {
+	// The left half is dense and the right half is sparse
+	m := density.NewMap(10, 10)
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 10; y++ {
+			m.Set(x, y, 4)
+		}
+	}
+
+	points := normgeom.NormPointGroup{{X: 0.1, Y: 0.05}, {X: 0.2, Y: 0.5}, {X: 0.7, Y: 0.5}}
+	budget := density.Budget{Max: normgeom.NormPoint{X: 0.1, Y: 0.1}, Points: 1}
+
+	method := NewDensityMethod(shiftMethod(0.01), m, budget)
+	method.Mutate(points, func(mutation Mutation) {
+		assert.Equal(t, mutation.New, points[mutation.Index])
+	})
+
+	mean := m.Mean()
+	assert.Equal(t, points[0].X, 0.1)
+	assert.InDelta(t, points[1].X, 0.2+0.01*math.Sqrt(mean/4), 1e-9)
+	assert.InDelta(t, points[2].X, 0.7+0.01*math.Sqrt(mean), 1e-9)
+}
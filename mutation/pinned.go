@@ -0,0 +1,31 @@
+package mutation
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// pinnedMethod wraps another Method so pinned points are never mutated.
+type pinnedMethod struct {
+	method Method
+	pins   normgeom.Pins
+}
+
+func (p pinnedMethod) Mutate(points normgeom.NormPointGroup, mutated func(mutation Mutation)) {
+	p.method.Mutate(points, func(mutation Mutation) {
+		if p.pins.Pinned(mutation.Index) {
+			// Undo the mutation
+			points[mutation.Index] = mutation.Old
+			return
+		}
+		mutated(mutation)
+	})
+}
+
+// NewPinnedMethod returns a Method which mutates points using another Method, except for the pinned points
+// which are always left unchanged. As mutations of pinned points are discarded, the mutation rate of the
+// other Method should be based on the number of points which aren't pinned.
+// If the other Method is already pinned, its pins are replaced.
+func NewPinnedMethod(method Method, pins normgeom.Pins) Method {
+	if p, ok := method.(pinnedMethod); ok {
+		method = p.method
+	}
+	return pinnedMethod{method: method, pins: pins}
+}
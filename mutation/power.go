@@ -0,0 +1,32 @@
+package mutation
+
+import "github.com/RH12503/Triangula/normgeom"
+
+// powerMethod wraps another Method so the weights of a power diagram are only mutated along the X axis.
+type powerMethod struct {
+	method Method
+}
+
+func (p powerMethod) Mutate(points normgeom.NormPointGroup, mutated func(mutation Mutation)) {
+	n := len(points) / 2
+
+	p.method.Mutate(points, func(mutation Mutation) {
+		if mutation.Index >= n {
+			// The Y coordinates of weights aren't used, so they're left unchanged
+			points[mutation.Index].Y = mutation.Old.Y
+			mutation.New = points[mutation.Index]
+
+			if mutation.New == mutation.Old {
+				return
+			}
+		}
+		mutated(mutation)
+	})
+}
+
+// NewPowerMethod returns a Method which mutates the point groups of power diagrams (see normgeom.WeightedSites)
+// using another Method, except only the X coordinates of the weights are mutated as their Y coordinates
+// aren't used.
+func NewPowerMethod(method Method) Method {
+	return powerMethod{method: method}
+}
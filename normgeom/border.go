@@ -0,0 +1,25 @@
+package normgeom
+
+// Border marks the points of a NormPointGroup which can only slide along the edge of the image they're on.
+// Border[i] is true if the point at index i is on the border; points past the end aren't.
+type Border []bool
+
+// OnBorder returns if the point at an index is on the border.
+func (b Border) OnBorder(i int) bool {
+	return i < len(b) && b[i]
+}
+
+// Slide returns a moved point after moving it back onto the edge of the image an old point is on, so only its
+// position along the edge changes. Points on a corner can't move, and points not on an edge are unchanged.
+func Slide(old, moved NormPoint) NormPoint {
+	moved.Constrain()
+
+	if old.X == 0 || old.X == 1 {
+		moved.X = old.X
+	}
+	if old.Y == 0 || old.Y == 1 {
+		moved.Y = old.Y
+	}
+
+	return moved
+}
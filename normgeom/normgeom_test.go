@@ -28,3 +28,26 @@ func TestNormPointGroup_Copy(t *testing.T) {
 
 	assert.Equal(t, a, b)
 }
+
+func TestPins(t *testing.T) {
+	pins := NewPins(5, 1, 3)
+
+	assert.Equal(t, pins.Pinned(1), true)
+	assert.Equal(t, pins.Pinned(2), false)
+	assert.Equal(t, pins.Pinned(10), false)
+	assert.Equal(t, pins.Indexes(), []int{1, 3})
+	assert.Equal(t, pins.Count(), 2)
+}
+
+func TestSlide(t *testing.T) {
+	assert.Equal(t, Slide(NormPoint{0, 0.5}, NormPoint{0.2, 0.7}), NormPoint{0, 0.7})
+	assert.Equal(t, Slide(NormPoint{0.3, 1}, NormPoint{0.1, 1.4}), NormPoint{0.1, 1})
+	assert.Equal(t, Slide(NormPoint{1, 0}, NormPoint{0.8, 0.2}), NormPoint{1, 0})
+	assert.Equal(t, Slide(NormPoint{0.5, 0.5}, NormPoint{0.2, 0.7}), NormPoint{0.2, 0.7})
+}
+
+func TestWeightedSites(t *testing.T) {
+	sites, weights := WeightedSites(NormPointGroup{{0.1, 0.2}, {0.3, 0.4}, {0.5, 0}, {0.9, 0}})
+	assert.Equal(t, sites, NormPointGroup{{0.1, 0.2}, {0.3, 0.4}})
+	assert.Equal(t, weights, []float64{0.5, 0.9})
+}
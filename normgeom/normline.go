@@ -0,0 +1,11 @@
+package normgeom
+
+// NormLine represents a line segment with normalized coordinates.
+type NormLine struct {
+	A, B NormPoint
+}
+
+// NewNormLine returns a new NormLine.
+func NewNormLine(x0, y0, x1, y1 float64) NormLine {
+	return NormLine{NormPoint{X: x0, Y: y0}, NormPoint{X: x1, Y: y1}}
+}
@@ -0,0 +1,41 @@
+package normgeom
+
+// Pins marks the points of a NormPointGroup which are locked in place, so optimization only moves the
+// other points. Pins[i] is true if the point at index i is pinned; points past the end aren't pinned.
+type Pins []bool
+
+// NewPins returns Pins for a group of n points with the points at the specified indexes pinned.
+func NewPins(n int, indexes ...int) Pins {
+	pins := make(Pins, n)
+	for _, i := range indexes {
+		pins[i] = true
+	}
+	return pins
+}
+
+// Pinned returns if the point at an index is pinned.
+func (p Pins) Pinned(i int) bool {
+	return i < len(p) && p[i]
+}
+
+// Indexes returns the indexes of the pinned points in increasing order.
+func (p Pins) Indexes() []int {
+	indexes := []int{}
+	for i, pinned := range p {
+		if pinned {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Count returns the number of pinned points.
+func (p Pins) Count() int {
+	count := 0
+	for _, pinned := range p {
+		if pinned {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,20 @@
+package normgeom
+
+// WeightedSites splits a point group used for a power diagram into its sites and their weights.
+// The first half of the group are the sites, and the X coordinate of each point in the second half is the
+// weight of the site with the same index, between 0 and 1. The Y coordinates of the weights aren't used
+// (mutation.NewPowerMethod leaves them unchanged).
+func WeightedSites(points NormPointGroup) (NormPointGroup, []float64) {
+	if len(points)%2 != 0 {
+		panic("the point group of a power diagram needs an even number of points")
+	}
+
+	n := len(points) / 2
+	weights := make([]float64, n)
+
+	for i := range weights {
+		weights[i] = points[n+i].X
+	}
+
+	return points[:n], weights
+}
@@ -7,14 +7,16 @@ import (
 	"math"
 )
 
+// Polygonate returns the cells of the Voronoi diagram of a point group as polygons.
+// It panics if w or h is larger than incrdelaunay.MaxSize.
 func Polygonate(points normgeom.NormPointGroup, w, h int) []geom.Polygon {
 	fW, fH := float64(w), float64(h)
 
 	triangulation := incrdelaunay.NewDelaunay(w, h)
 	for _, p := range points {
 		triangulation.Insert(incrdelaunay.Point{
-			X: int16(math.Round(p.X * fW)),
-			Y: int16(math.Round(p.Y * fH)),
+			X: int32(math.Round(p.X * fW)),
+			Y: int32(math.Round(p.Y * fH)),
 		})
 	}
 
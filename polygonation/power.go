@@ -0,0 +1,46 @@
+package polygonation
+
+import (
+	"github.com/RH12503/Triangula/geom"
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"math"
+)
+
+// PowerPolygonate returns the cells of the power diagram of a point group created with
+// generator.NewPowerGenerator (see normgeom.WeightedSites). Sites without a cell are skipped.
+func PowerPolygonate(points normgeom.NormPointGroup, w, h int) []geom.Polygon {
+	fW, fH := float64(w), float64(h)
+
+	sites, weights := normgeom.WeightedSites(points)
+	maxWeight := incrdelaunay.MaxPowerWeight(len(sites), w, h)
+
+	diagram := incrdelaunay.NewPowerDiagram(w, h, maxWeight)
+	for i, p := range sites {
+		diagram.Update(i, incrdelaunay.Point{
+			X: int32(math.Round(p.X * fW)),
+			Y: int32(math.Round(p.Y * fH)),
+		}, weights[i]*maxWeight)
+	}
+
+	var polygons []geom.Polygon
+
+	diagram.IterPolygons(func(points []incrdelaunay.FloatPoint) {
+		var polygon geom.Polygon
+
+		for _, p := range points {
+			new := geom.Point{
+				X: int(math.Round(p.X)),
+				Y: int(math.Round(p.Y)),
+			}
+
+			if len(polygon.Points) == 0 || polygon.Points[len(polygon.Points)-1] != new {
+				polygon.Points = append(polygon.Points, new)
+			}
+		}
+
+		polygons = append(polygons, polygon)
+	})
+
+	return polygons
+}
@@ -8,6 +8,13 @@ func DDAPolygonBlocks(polygon geom.Polygon, blockSize int, line func(x0, x1, y i
 	})
 }
 
+// DDAPolygonLines calls function line for each horizontal line a convex geom.Polygon covers.
+func DDAPolygonLines(polygon geom.Polygon, line func(x0, x1, y int)) {
+	polygon.Triangulate(func(triangle geom.Triangle) {
+		DDATriangleLines(triangle, line)
+	})
+}
+
 func DDAPolygon(polygon geom.Polygon, pixel func(x, y int)) {
 	polygon.Triangulate(func(triangle geom.Triangle) {
 		DDATriangle(triangle, pixel)
@@ -44,3 +44,18 @@ func TestDDATriangleBlocks(t *testing.T) {
 	assert.Equal(t, lines, 77)
 	assert.Equal(t, blocks, 129)
 }
+
+func TestScanlinePolygonLines(t *testing.T) {
+	// A non-convex L shape
+	polygon := geom.Polygon{Points: []geom.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 10}, {X: 0, Y: 10}}}
+
+	pixels := 0
+	covered := map[geom.Point]bool{}
+	ScanlinePolygon(polygon, func(x, y int) {
+		pixels++
+		covered[geom.Point{X: x, Y: y}] = true
+	})
+	assert.Equal(t, pixels, 64)
+	assert.Equal(t, covered[geom.Point{X: 7, Y: 7}], false)
+	assert.Equal(t, covered[geom.Point{X: 9, Y: 3}], true)
+}
@@ -0,0 +1,64 @@
+package rasterize
+
+import (
+	"github.com/RH12503/Triangula/geom"
+	"math"
+	"sort"
+)
+
+// ScanlinePolygonLines calls function line for each horizontal line of pixels a geom.Polygon covers.
+// Unlike DDAPolygonLines the polygon can be non-convex. A pixel is covered if its center is inside the polygon
+// (using the even-odd rule), so polygons sharing edges never overlap or leave gaps between them.
+func ScanlinePolygonLines(polygon geom.Polygon, line func(x0, x1, y int)) {
+	points := polygon.Points
+	if len(points) < 3 {
+		return
+	}
+
+	minY, maxY := points[0].Y, points[0].Y
+	for _, p := range points {
+		if p.Y < minY {
+			minY = p.Y
+		} else if p.Y > maxY {
+			maxY = p.Y
+		}
+	}
+
+	var crossings []float64
+
+	for y := minY; y < maxY; y++ {
+		center := float64(y) + 0.5
+		crossings = crossings[:0]
+
+		// Find where the edges cross the center of the row
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+
+			if (float64(a.Y) <= center) != (float64(b.Y) <= center) {
+				t := (center - float64(a.Y)) / float64(b.Y-a.Y)
+				crossings = append(crossings, float64(a.X)+t*float64(b.X-a.X))
+			}
+		}
+
+		sort.Float64s(crossings)
+
+		// The pixels whose centers are between each pair of crossings are inside
+		for i := 1; i < len(crossings); i += 2 {
+			x0 := int(math.Ceil(crossings[i-1] - 0.5))
+			x1 := int(math.Ceil(crossings[i] - 0.5))
+
+			if x0 < x1 {
+				line(x0, x1, y)
+			}
+		}
+	}
+}
+
+// ScanlinePolygon calls function pixel for each pixel a geom.Polygon covers, like ScanlinePolygonLines.
+func ScanlinePolygon(polygon geom.Polygon, pixel func(x, y int)) {
+	ScanlinePolygonLines(polygon, func(x0, x1, y int) {
+		for x := x0; x < x1; x++ {
+			pixel(x, y)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/normgeom"
+)
+
+// Darken returns a ColorFunc which mixes the fill color of each shape with black, where an amount of 0 is the
+// fill color and 1 is black. Used with WithStroke it gives a wireframe which follows the colors of the image.
+func Darken(amount float64) ColorFunc {
+	return func(fill color.RGB) color.RGB {
+		return mixColor(fill, color.RGB{}, amount)
+	}
+}
+
+// Lighten returns a ColorFunc which mixes the fill color of each shape with white, where an amount of 0 is the
+// fill color and 1 is white.
+func Lighten(amount float64) ColorFunc {
+	return func(fill color.RGB) color.RGB {
+		return mixColor(fill, color.RGB{R: 1, G: 1, B: 1}, amount)
+	}
+}
+
+// mixColor linearly interpolates between two colors.
+func mixColor(a, b color.RGB, t float64) color.RGB {
+	return color.RGB{
+		R: a.R + (b.R-a.R)*t,
+		G: a.G + (b.G-a.G)*t,
+		B: a.B + (b.B-a.B)*t,
+	}
+}
+
+// WithVertices draws a dot with a radius in pixels at each vertex of the shapes, on top of the shapes.
+// The color of a dot is based on the fill color of the last shape with the vertex.
+func WithVertices(radius float64, c ColorFunc) Option {
+	return func(o *options) {
+		o.vertexRadius = radius
+		o.vertexColor = c
+	}
+}
+
+// WithOutlineOnly doesn't fill the shapes, so only their strokes (and vertices) are drawn. If WithStroke isn't
+// used, the edges of each shape are drawn 1 pixel wide with its fill color.
+func WithOutlineOnly() Option {
+	return func(o *options) {
+		o.outlineOnly = true
+	}
+}
+
+// vertex is a vertex drawn by WithVertices.
+type vertex struct {
+	point normgeom.NormPoint
+	color color.RGB
+}
+
+// vertices returns each distinct vertex of a group of shapes in the order they first appear, with its color.
+func vertices(shapes []Shape, c ColorFunc) []vertex {
+	var vertices []vertex
+	indexes := map[normgeom.NormPoint]int{}
+
+	for _, s := range shapes {
+		if len(s.Points) < 3 {
+			continue
+		}
+
+		for _, p := range s.Points {
+			i, ok := indexes[p]
+			if !ok {
+				i = len(vertices)
+				indexes[p] = i
+				vertices = append(vertices, vertex{point: p})
+			}
+			vertices[i].color = c(s.Color)
+		}
+	}
+
+	return vertices
+}
@@ -0,0 +1,29 @@
+package render
+
+import (
+	"io"
+	"math"
+)
+
+// WriteEPS writes shapes (see TriangleShapes and PolygonShapes) as an Encapsulated PostScript file of filled
+// paths, where width and height are the size of the image in pixels. The options are the same as WritePDF,
+// with the bounding box of the file being the page.
+func WriteEPS(w io.Writer, shapes []Shape, width, height int, opts ...Option) error {
+	o := newOptions(opts)
+	l := o.newPageLayout(width, height)
+
+	out := &errWriter{w: w}
+
+	out.printf("%%!PS-Adobe-3.0 EPSF-3.0\n")
+	out.printf("%%%%BoundingBox: 0 0 %v %v\n", math.Ceil(l.width), math.Ceil(l.height))
+	out.printf("%%%%HiResBoundingBox: 0 0 %v %v\n", o.pageNum(l.width), o.pageNum(l.height))
+	out.printf("%%%%Creator: Triangula\n")
+	out.printf("%%%%LanguageLevel: 2\n")
+	out.printf("%%%%EndComments\n")
+
+	out.printf("gsave\n10 dict begin\n%v", postScriptProcs)
+	writePagePaths(out, shapes, width, height, l, o, true)
+	out.printf("end\ngrestore\nshowpage\n%%%%EOF\n")
+
+	return out.err
+}
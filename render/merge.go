@@ -0,0 +1,363 @@
+package render
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/geom"
+	"math"
+	"sort"
+)
+
+// mergeEdge is a directed edge of a polygon.
+type mergeEdge struct {
+	a, b geom.Point
+}
+
+// mergeGroup is a group of merged polygons.
+type mergeGroup struct {
+	parent int // The index of another polygon in the group, or itself if it's the root.
+
+	area  float64   // The total area of the polygons, which weights their colors.
+	color color.RGB // The color of the group, weighted by the area of each polygon.
+}
+
+// MergePolygons merges neighboring polygons (such as the cells of polygonation.Polygonate) whose colors are
+// within a tolerance of each other into larger polygons, which can be non-convex. data[i] is the color of
+// polygons[i], such as from PolygonsOnImage, and w and h are the size of the image. Polygons are neighbors if
+// they share any part of an edge, including where a vertex of one polygon lies on the edge of another.
+//
+// The tolerance is the largest distance between two RGB colors (between 0 and 1) which are merged. The color of
+// a merged polygon is the average color of its polygons, weighted by their area.
+//
+// Each merged polygon is a single path around its outside. A merged polygon can surround other polygons, so the
+// polygons are returned from largest to smallest and must be drawn in that order (using an even-odd fill such as
+// rasterize.ScanlinePolygonLines), which draws the surrounded polygons on top.
+func MergePolygons(polygons []geom.Polygon, data []PolygonData, w, h int, tolerance float64) []PolygonData {
+	groups := make([]mergeGroup, len(polygons))
+	cells := make([][]geom.Point, len(polygons))
+
+	// The polygon each directed edge belongs to, with the points of every polygon in the same winding order
+	owners := map[mergeEdge]int{}
+
+	for i, poly := range polygons {
+		points := poly.Points
+		area := signedArea(points)
+
+		if area < 0 {
+			points = make([]geom.Point, len(poly.Points))
+			for j, p := range poly.Points {
+				points[len(points)-1-j] = p
+			}
+		}
+
+		cells[i] = points
+		groups[i] = mergeGroup{parent: i, area: math.Abs(area), color: data[i].Color}
+	}
+
+	// Split edges at the vertices of other polygons lying on them (T-junctions), so polygons sharing
+	// only part of an edge still have matching edges
+	vertices := newVertexGrid(cells, w, h)
+	for i, points := range cells {
+		cells[i] = vertices.split(points)
+
+		iterEdges(cells[i], func(e mergeEdge) {
+			owners[e] = i
+		})
+	}
+
+	// Find the neighbors of each polygon, which share an edge in the opposite direction
+	type neighbors struct {
+		a, b int
+		dist float64
+	}
+	var pairs []neighbors
+
+	for e, i := range owners {
+		if j, ok := owners[mergeEdge{e.b, e.a}]; ok && i < j {
+			pairs = append(pairs, neighbors{i, j, color.DistSq(data[i].Color, data[j].Color)})
+		}
+	}
+
+	// Merge the most similar neighbors first, comparing the colors of their groups so the colors of
+	// a group can't drift further than the tolerance
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].dist != pairs[j].dist {
+			return pairs[i].dist < pairs[j].dist
+		}
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+
+	for _, p := range pairs {
+		a, b := findGroup(groups, p.a), findGroup(groups, p.b)
+		if a == b || color.DistSq(groups[a].color, groups[b].color) > tolerance*tolerance {
+			continue
+		}
+
+		ga, gb := groups[a], groups[b]
+		area := ga.area + gb.area
+
+		if area > 0 {
+			groups[a].color = color.RGB{
+				R: (ga.color.R*ga.area + gb.color.R*gb.area) / area,
+				G: (ga.color.G*ga.area + gb.color.G*gb.area) / area,
+				B: (ga.color.B*ga.area + gb.color.B*gb.area) / area,
+			}
+		}
+		groups[a].area = area
+		groups[b].parent = a
+	}
+
+	// Find the edges on the outside of each group, which don't have a neighbor in the same group
+	outlines := map[int]map[geom.Point][]geom.Point{}
+	var roots []int
+
+	for i, points := range cells {
+		g := findGroup(groups, i)
+
+		iterEdges(points, func(e mergeEdge) {
+			if j, ok := owners[mergeEdge{e.b, e.a}]; ok && findGroup(groups, j) == g {
+				return
+			}
+
+			outline, ok := outlines[g]
+			if !ok {
+				outline = map[geom.Point][]geom.Point{}
+				outlines[g] = outline
+				roots = append(roots, g)
+			}
+			outline[e.a] = append(outline[e.a], e.b)
+		})
+	}
+
+	type mergedPolygon struct {
+		polygon geom.Polygon
+		area    float64
+		color   color.RGB
+	}
+	var merged []mergedPolygon
+
+	for _, g := range roots {
+		for _, loop := range traceOutline(outlines[g]) {
+			loop = removeCollinear(loop)
+
+			// Loops winding the other way are holes, which are filled by the polygons inside them
+			if area := signedArea(loop); area > 0 {
+				merged = append(merged, mergedPolygon{geom.Polygon{Points: loop}, area, groups[g].color})
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].area > merged[j].area
+	})
+
+	polygonData := make([]PolygonData, len(merged))
+	for i, m := range merged {
+		polygonData[i] = PolygonData{
+			Polygon: m.polygon.ToNorm(w, h),
+			Color:   m.color,
+		}
+	}
+
+	return polygonData
+}
+
+// vertexGrid stores the unique vertices of a group of polygons in square buckets, to quickly find the
+// vertices lying on an edge.
+type vertexGrid struct {
+	size    int                         // The width and height of each bucket.
+	buckets map[geom.Point][]geom.Point // The vertices in each bucket, keyed by the bucket's position.
+}
+
+// newVertexGrid returns a vertexGrid of the vertices of a group of polygons in a w by h image.
+func newVertexGrid(polygons [][]geom.Point, w, h int) vertexGrid {
+	unique := map[geom.Point]bool{}
+	for _, points := range polygons {
+		for _, p := range points {
+			unique[p] = true
+		}
+	}
+
+	// Around one vertex per bucket
+	size := 1
+	if len(unique) > 0 {
+		size = int(math.Max(1, math.Sqrt(float64(w*h)/float64(len(unique)))))
+	}
+
+	g := vertexGrid{size: size, buckets: map[geom.Point][]geom.Point{}}
+	for p := range unique {
+		b := g.bucket(p)
+		g.buckets[b] = append(g.buckets[b], p)
+	}
+
+	return g
+}
+
+// bucket returns the position of the bucket containing a point.
+func (g vertexGrid) bucket(p geom.Point) geom.Point {
+	return geom.Point{X: floorDiv(p.X, g.size), Y: floorDiv(p.Y, g.size)}
+}
+
+// split returns the points of a polygon with the vertices lying inside each of its edges inserted in order.
+func (g vertexGrid) split(points []geom.Point) []geom.Point {
+	var split []geom.Point
+
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		split = append(split, a)
+
+		if a == b {
+			continue
+		}
+
+		dX, dY := b.X-a.X, b.Y-a.Y
+		length := dX*dX + dY*dY
+
+		start := len(split)
+
+		min, max := g.bucket(a), g.bucket(b)
+		if min.X > max.X {
+			min.X, max.X = max.X, min.X
+		}
+		if min.Y > max.Y {
+			min.Y, max.Y = max.Y, min.Y
+		}
+
+		for bX := min.X; bX <= max.X; bX++ {
+			for bY := min.Y; bY <= max.Y; bY++ {
+				for _, v := range g.buckets[geom.Point{X: bX, Y: bY}] {
+					// The vertex must be collinear with the edge and strictly between its endpoints
+					vX, vY := v.X-a.X, v.Y-a.Y
+					if dX*vY-dY*vX != 0 {
+						continue
+					}
+					if dot := dX*vX + dY*vY; dot > 0 && dot < length {
+						split = append(split, v)
+					}
+				}
+			}
+		}
+
+		inside := split[start:]
+		sort.Slice(inside, func(i, j int) bool {
+			return dX*(inside[i].X-a.X)+dY*(inside[i].Y-a.Y) < dX*(inside[j].X-a.X)+dY*(inside[j].Y-a.Y)
+		})
+	}
+
+	return split
+}
+
+// floorDiv returns a divided by b, rounded down.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// findGroup returns the root of the group of a polygon.
+func findGroup(groups []mergeGroup, i int) int {
+	for groups[i].parent != i {
+		groups[i].parent = groups[groups[i].parent].parent
+		i = groups[i].parent
+	}
+	return i
+}
+
+// iterEdges calls function edge for each directed edge of a polygon, skipping edges with a length of zero.
+func iterEdges(points []geom.Point, edge func(e mergeEdge)) {
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		if a != b {
+			edge(mergeEdge{a, b})
+		}
+	}
+}
+
+// traceOutline joins the edges of an outline into closed loops. Where an outline touches itself at a point,
+// the sharpest turn is taken so the loops are joined together.
+func traceOutline(outline map[geom.Point][]geom.Point) [][]geom.Point {
+	// Start from the points in a consistent order so the result is deterministic
+	var starts []geom.Point
+	for p := range outline {
+		starts = append(starts, p)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Y < starts[j].Y || (starts[i].Y == starts[j].Y && starts[i].X < starts[j].X)
+	})
+
+	var loops [][]geom.Point
+
+	for _, start := range starts {
+		for len(outline[start]) > 0 {
+			loop := []geom.Point{start}
+
+			prev, p := start, outline[start][0]
+			outline[start] = outline[start][1:]
+
+			for p != start {
+				next := outline[p]
+				if len(next) == 0 {
+					// The outline isn't closed, which can only happen with invalid polygons
+					break
+				}
+
+				// Take the most clockwise turn
+				best := 0
+				bestAngle := math.Inf(1)
+				for i, n := range next {
+					dX0, dY0 := float64(p.X-prev.X), float64(p.Y-prev.Y)
+					dX1, dY1 := float64(n.X-p.X), float64(n.Y-p.Y)
+
+					angle := math.Atan2(dX0*dY1-dY0*dX1, dX0*dX1+dY0*dY1)
+					if angle < bestAngle {
+						best, bestAngle = i, angle
+					}
+				}
+
+				loop = append(loop, p)
+				prev, p = p, next[best]
+
+				next[best] = next[len(next)-1]
+				outline[prev] = next[:len(next)-1]
+			}
+
+			loops = append(loops, loop)
+		}
+	}
+
+	return loops
+}
+
+// removeCollinear removes the points of a loop which lie on a straight line between their neighbors.
+func removeCollinear(loop []geom.Point) []geom.Point {
+	for removed := true; removed && len(loop) > 2; {
+		removed = false
+
+		for i := 0; i < len(loop) && len(loop) > 2; i++ {
+			prev := loop[(i+len(loop)-1)%len(loop)]
+			p := loop[i]
+			next := loop[(i+1)%len(loop)]
+
+			if (p.X-prev.X)*(next.Y-p.Y)-(p.Y-prev.Y)*(next.X-p.X) == 0 {
+				loop = append(loop[:i], loop[i+1:]...)
+				removed = true
+				i--
+			}
+		}
+	}
+
+	return loop
+}
+
+// signedArea returns the area of a polygon, which is negative if its points are in the opposite winding order.
+func signedArea(points []geom.Point) float64 {
+	area := 0
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return float64(area) / 2
+}
@@ -0,0 +1,141 @@
+package render
+
+import "github.com/RH12503/Triangula/color"
+
+// An Option configures how shapes are written by the outputs of the package.
+// Options which don't apply to an output are ignored.
+type Option func(o *options)
+
+// PageSize is the size of a page in points (1/72 of an inch).
+type PageSize struct {
+	Width, Height float64
+}
+
+var (
+	PageA4     = PageSize{Width: 595.28, Height: 841.89}
+	PageLetter = PageSize{Width: 612, Height: 792}
+)
+
+// A ColorFunc returns the color used to draw part of a shape, given the shape's fill color.
+type ColorFunc func(fill color.RGB) color.RGB
+
+// FillColor returns a ColorFunc which uses the fill color of each shape.
+func FillColor() ColorFunc {
+	return func(fill color.RGB) color.RGB {
+		return fill
+	}
+}
+
+// SolidColor returns a ColorFunc which always uses the same color.
+func SolidColor(c color.RGB) ColorFunc {
+	return func(color.RGB) color.RGB {
+		return c
+	}
+}
+
+// options stores the configuration of an output.
+type options struct {
+	background *color.RGB // If not nil, the color drawn behind the shapes.
+
+	strokeWidth float64   // The width of the lines along the edges of shapes, in pixels. 0 means no lines.
+	strokeColor ColorFunc // The color of the lines along the edges of shapes.
+
+	vertexRadius float64   // The radius of the dots at the vertices of shapes, in pixels. 0 means no dots.
+	vertexColor  ColorFunc // The color of the dots at the vertices of shapes.
+
+	outlineOnly bool // If shapes aren't filled.
+
+	precision int  // The number of decimal places of coordinates in vector outputs.
+	merge     bool // If shapes with the same color are written as a single path in vector outputs.
+
+	samples int // The number of samples along each side of a pixel in raster outputs.
+
+	// The layout of page outputs. If the page is nil, the page is the size of the image at the DPI.
+	page   *PageSize
+	margin float64
+	dpi    float64
+}
+
+// newOptions returns the options after applying a group of Option's.
+func newOptions(opts []Option) *options {
+	o := &options{precision: 2, samples: 4, dpi: 72}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Shapes which aren't filled need to be stroked to be seen
+	if o.outlineOnly && o.strokeWidth <= 0 {
+		o.strokeWidth = 1
+		o.strokeColor = FillColor()
+	}
+
+	return o
+}
+
+// WithBackground fills the output with a color before drawing the shapes.
+func WithBackground(c color.RGB) Option {
+	return func(o *options) {
+		o.background = &c
+	}
+}
+
+// WithStroke draws lines along the edges of the shapes with a width in pixels. Stroking shapes with their
+// FillColor hides the thin gaps some renderers leave between shapes which share an edge.
+func WithStroke(width float64, c ColorFunc) Option {
+	return func(o *options) {
+		o.strokeWidth = width
+		o.strokeColor = c
+	}
+}
+
+// WithPrecision sets the number of decimal places of the coordinates written by vector outputs. The default is 2.
+func WithPrecision(decimals int) Option {
+	return func(o *options) {
+		o.precision = decimals
+	}
+}
+
+// WithMerge writes all the shapes with the same color as a single path in vector outputs,
+// which makes files much smaller.
+func WithMerge() Option {
+	return func(o *options) {
+		o.merge = true
+	}
+}
+
+// WithSamples sets the number of samples along each side of a pixel used to anti-alias raster outputs, so each
+// pixel is the average of samples² samples. The default is 4, and 1 disables anti-aliasing.
+func WithSamples(n int) Option {
+	if n < 1 {
+		panic("the number of samples must be at least 1")
+	}
+	return func(o *options) {
+		o.samples = n
+	}
+}
+
+// WithPage sets the size of the page of page outputs (PDF and EPS). The image is scaled to fit inside the margins
+// of the page and centered, ignoring the DPI.
+func WithPage(size PageSize) Option {
+	return func(o *options) {
+		o.page = &size
+	}
+}
+
+// WithMargin sets the space in points around the image in page outputs. The default is 0.
+func WithMargin(margin float64) Option {
+	return func(o *options) {
+		o.margin = margin
+	}
+}
+
+// WithDPI sets the number of pixels of the image per inch in page outputs without a page size, which sets
+// the printed size of the image. The default is 72, so each pixel is a point.
+func WithDPI(dpi float64) Option {
+	if dpi <= 0 {
+		panic("the DPI must be positive")
+	}
+	return func(o *options) {
+		o.dpi = dpi
+	}
+}
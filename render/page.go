@@ -0,0 +1,172 @@
+package render
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"math"
+)
+
+// pageLayout is the position of an image on the page of a page output, in points from the bottom left.
+type pageLayout struct {
+	width, height float64 // The size of the page.
+	x, y          float64 // The position of the top left of the image, from the top left of the page.
+	scale         float64 // The number of points per pixel.
+	imageW        float64
+	imageH        float64
+}
+
+// newPageLayout calculates the layout of an image with a width and height in pixels.
+func (o *options) newPageLayout(width, height int) pageLayout {
+	w, h := float64(width), float64(height)
+
+	if o.page == nil {
+		scale := 72 / o.dpi
+		return pageLayout{
+			width:  w*scale + 2*o.margin,
+			height: h*scale + 2*o.margin,
+			x:      o.margin,
+			y:      o.margin,
+			scale:  scale,
+			imageW: w * scale,
+			imageH: h * scale,
+		}
+	}
+
+	l := pageLayout{width: o.page.Width, height: o.page.Height}
+	l.scale = math.Max(0, math.Min((l.width-2*o.margin)/w, (l.height-2*o.margin)/h))
+	l.imageW, l.imageH = w*l.scale, h*l.scale
+	l.x, l.y = (l.width-l.imageW)/2, (l.height-l.imageH)/2
+
+	return l
+}
+
+// point converts the coordinates of a point in pixels to points from the bottom left of the page.
+func (l pageLayout) point(x, y float64) (float64, float64) {
+	return l.x + x*l.scale, l.height - l.y - y*l.scale
+}
+
+// writePagePaths writes the background and shapes of a page output using the operators of PDF,
+// or of PostScript if ps is true. The PostScript operators are defined by postScriptProcs.
+func writePagePaths(out *errWriter, shapes []Shape, width, height int, l pageLayout, o *options, ps bool) {
+	if o.background != nil {
+		x, y := l.point(0, float64(height))
+		out.printf("%v rg\n", pageColor(*o.background))
+		if ps {
+			out.printf("%v %v %v %v rectfill\n", o.pageNum(x), o.pageNum(y), o.pageNum(l.imageW), o.pageNum(l.imageH))
+		} else {
+			out.printf("%v %v %v %v re f\n", o.pageNum(x), o.pageNum(y), o.pageNum(l.imageW), o.pageNum(l.imageH))
+		}
+	}
+
+	stroke := o.strokeWidth > 0
+	if stroke {
+		if ps {
+			out.printf("%v setlinewidth 1 setlinecap 1 setlinejoin\n", formatFloat(o.strokeWidth*l.scale, -1))
+		} else {
+			out.printf("%v w 1 J 1 j\n", formatFloat(o.strokeWidth*l.scale, -1))
+		}
+	}
+
+	groups := [][]Shape{}
+	if o.merge {
+		groups = groupByColor(shapes)
+	} else {
+		for _, s := range shapes {
+			groups = append(groups, []Shape{s})
+		}
+	}
+
+	for _, group := range groups {
+		c := group[0].Color
+
+		if !o.outlineOnly {
+			out.printf("%v rg\n", pageColor(c))
+		}
+		if stroke && !ps {
+			out.printf("%v RG\n", pageColor(o.strokeColor(c)))
+		}
+
+		empty := true
+
+		for _, s := range group {
+			if len(s.Points) < 3 {
+				continue
+			}
+			empty = false
+
+			for i, p := range s.Points {
+				x, y := l.point(p.X*float64(width), p.Y*float64(height))
+				op := "l"
+				if i == 0 {
+					op = "m"
+				}
+				out.printf("%v %v %v\n", o.pageNum(x), o.pageNum(y), op)
+			}
+			out.printf("h\n")
+		}
+
+		switch {
+		case empty:
+		case o.outlineOnly && ps:
+			out.printf("%v S\n", pageColor(o.strokeColor(c)))
+		case o.outlineOnly:
+			out.printf("S\n")
+		case stroke && ps:
+			out.printf("%v b\n", pageColor(o.strokeColor(c)))
+		case stroke:
+			out.printf("b\n")
+		default:
+			out.printf("f\n")
+		}
+	}
+
+	if o.vertexRadius > 0 {
+		r := o.vertexRadius * l.scale
+
+		for _, v := range vertices(shapes, o.vertexColor) {
+			x, y := l.point(v.point.X*float64(width), v.point.Y*float64(height))
+			out.printf("%v rg\n", pageColor(v.color))
+
+			if ps {
+				out.printf("%v %v %v d\n", o.pageNum(x), o.pageNum(y), formatFloat(r, -1))
+				continue
+			}
+
+			// A circle made of four Bézier curves
+			k := r * circleKappa
+			out.printf("%v %v m\n", o.pageNum(x+r), o.pageNum(y))
+			out.printf("%v %v %v %v %v %v c\n", o.pageNum(x+r), o.pageNum(y+k), o.pageNum(x+k), o.pageNum(y+r), o.pageNum(x), o.pageNum(y+r))
+			out.printf("%v %v %v %v %v %v c\n", o.pageNum(x-k), o.pageNum(y+r), o.pageNum(x-r), o.pageNum(y+k), o.pageNum(x-r), o.pageNum(y))
+			out.printf("%v %v %v %v %v %v c\n", o.pageNum(x-r), o.pageNum(y-k), o.pageNum(x-k), o.pageNum(y-r), o.pageNum(x), o.pageNum(y-r))
+			out.printf("%v %v %v %v %v %v c\n", o.pageNum(x+k), o.pageNum(y-r), o.pageNum(x+r), o.pageNum(y-k), o.pageNum(x+r), o.pageNum(y))
+			out.printf("f\n")
+		}
+	}
+}
+
+// circleKappa is the distance of the control points of a Bézier curve approximating a quarter of a circle,
+// relative to the radius.
+const circleKappa = 0.5522847498
+
+// postScriptProcs defines the operators used by writePagePaths which aren't built into PostScript.
+// Unlike PDF, b and S take the color of the stroke, and d draws a filled circle.
+const postScriptProcs = `/m {moveto} bind def
+/l {lineto} bind def
+/h {closepath} bind def
+/f {fill} bind def
+/b {gsave fill grestore setrgbcolor stroke} bind def
+/S {setrgbcolor stroke} bind def
+/d {newpath 0 360 arc fill} bind def
+/rg {setrgbcolor} bind def
+`
+
+// pageNum formats a coordinate of a page output.
+func (o *options) pageNum(v float64) string {
+	return formatFloat(v, o.precision)
+}
+
+// pageColor formats a color as the operands of the PDF and PostScript color operators.
+func pageColor(c color.RGB) string {
+	return formatFloat(float64(to8Bit(c.R))/255, 3) + " " +
+		formatFloat(float64(to8Bit(c.G))/255, 3) + " " +
+		formatFloat(float64(to8Bit(c.B))/255, 3)
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// WritePDF writes shapes (see TriangleShapes and PolygonShapes) as a single page PDF document of filled paths,
+// where width and height are the size of the image in pixels. WithPage, WithMargin and WithDPI set the layout
+// of the page, and the other options configure the paths and effects like WriteSVG.
+func WritePDF(w io.Writer, shapes []Shape, width, height int, opts ...Option) error {
+	o := newOptions(opts)
+	l := o.newPageLayout(width, height)
+
+	// The content stream, compressed
+	var content bytes.Buffer
+	z := zlib.NewWriter(&content)
+
+	out := &errWriter{w: z}
+	writePagePaths(out, shapes, width, height, l, o, false)
+	if out.err != nil {
+		return out.err
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+
+	var doc bytes.Buffer
+	var offsets []int
+
+	doc.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object := func(format string, a ...interface{}) {
+		offsets = append(offsets, doc.Len())
+		fmt.Fprintf(&doc, "%v 0 obj\n", len(offsets))
+		fmt.Fprintf(&doc, format, a...)
+		doc.WriteString("\nendobj\n")
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] /Contents 4 0 R >>",
+		o.pageNum(l.width), o.pageNum(l.height))
+	object("<< /Length %v /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes())
+
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %v\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %v /Root 1 0 R >>\nstartxref\n%v\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
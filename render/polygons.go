@@ -50,3 +50,30 @@ func PolygonsOnImage(polygons []geom.Polygon, image image.Data) []PolygonData {
 
 	return polygonData
 }
+
+// PolygonsOnImagePalette calculates the color of each polygon like PolygonsOnImage, but only using colors
+// from a palette. The index in the palette of each polygon's color is returned alongside the polygons.
+// If grayscale is true, colors are matched by their luminance, like fitness functions using fitness.WithGrayscale.
+func PolygonsOnImagePalette(polygons []geom.Polygon, image image.Data, palette color.Palette, grayscale bool) ([]PolygonData, []int) {
+	polygonData := PolygonsOnImage(polygons, image)
+	indexes := make([]int, len(polygonData))
+
+	for i := range polygonData {
+		indexes[i] = palette.Match(polygonData[i].Color, grayscale)
+		polygonData[i].Color = palette[indexes[i]]
+	}
+
+	return polygonData, indexes
+}
+
+// PolygonsOnImageTone calculates the average luminance of each polygon, and colors the polygons by mapping
+// their luminance with a color.ToneMap (such as color.Gray or color.Duotone).
+func PolygonsOnImageTone(polygons []geom.Polygon, image image.Data, tone color.ToneMap) []PolygonData {
+	polygonData := PolygonsOnImage(polygons, image)
+
+	for i := range polygonData {
+		polygonData[i].Color = tone(color.Luminance(polygonData[i].Color))
+	}
+
+	return polygonData
+}
@@ -0,0 +1,223 @@
+package render
+
+import (
+	"github.com/RH12503/Triangula/color"
+	stdimage "image"
+	"math"
+	"sort"
+)
+
+// rasterBand is the number of rows of pixels sampled at once by DrawShapes, which limits the memory used
+// for very large images.
+const rasterBand = 16
+
+// rasterShape is a Shape in the coordinates of the samples of an image.
+type rasterShape struct {
+	xs, ys     []float64
+	minY, maxY float64
+	color      [4]float64 // The premultiplied RGBA color, between 0 and 1.
+}
+
+// newRasterShape returns a rasterShape with points in the coordinates of the samples.
+func newRasterShape(xs, ys []float64, c color.RGB) rasterShape {
+	r := rasterShape{
+		xs:    xs,
+		ys:    ys,
+		minY:  math.Inf(1),
+		maxY:  math.Inf(-1),
+		color: [4]float64{c.R, c.G, c.B, 1},
+	}
+
+	for _, y := range ys {
+		r.minY = math.Min(r.minY, y)
+		r.maxY = math.Max(r.maxY, y)
+	}
+
+	return r
+}
+
+// circlePoints returns the points of a polygon approximating a circle, with enough points that
+// it's within half a sample of the circle.
+func circlePoints(x, y, radius float64) ([]float64, []float64) {
+	n := int(math.Min(64, math.Max(8, math.Ceil(math.Pi*radius))))
+	xs, ys := make([]float64, n), make([]float64, n)
+
+	for i := range xs {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		xs[i] = x + math.Cos(angle)*radius
+		ys[i] = y + math.Sin(angle)*radius
+	}
+
+	return xs, ys
+}
+
+// DrawShapes draws shapes (see TriangleShapes and PolygonShapes) onto an image, scaling them to fill its
+// bounds, so they can be drawn at any resolution. Later shapes are drawn on top of earlier ones.
+//
+// Each pixel is anti-aliased by averaging a grid of samples (see WithSamples), where each sample takes the
+// color of the last shape covering it. Shapes sharing an edge split the samples along it between them,
+// so there aren't any seams between them. Samples which aren't covered by a shape keep the color of the image,
+// or the color of WithBackground. The strokes of WithStroke and vertices of WithVertices are drawn the same way.
+func DrawShapes(dst *stdimage.RGBA, shapes []Shape, opts ...Option) {
+	o := newOptions(opts)
+
+	bounds := dst.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	s := o.samples
+	bandSize := float64(rasterBand * s)
+	numBands := (h + rasterBand - 1) / rasterBand
+
+	scaleX, scaleY := float64(w*s), float64(h*s)
+	var raster []rasterShape
+
+	for _, shape := range shapes {
+		if len(shape.Points) < 3 {
+			continue
+		}
+
+		if !o.outlineOnly {
+			xs, ys := make([]float64, len(shape.Points)), make([]float64, len(shape.Points))
+			for i, p := range shape.Points {
+				xs[i], ys[i] = p.X*scaleX, p.Y*scaleY
+			}
+			raster = append(raster, newRasterShape(xs, ys, shape.Color))
+		}
+
+		// Each part of the stroke is a separate shape, as parts overlapping would cancel out with the even-odd rule
+		if o.strokeWidth > 0 {
+			c := o.strokeColor(shape.Color)
+			radius := o.strokeWidth / 2 * float64(s)
+
+			for i, p := range shape.Points {
+				aX, aY := p.X*scaleX, p.Y*scaleY
+				q := shape.Points[(i+1)%len(shape.Points)]
+				bX, bY := q.X*scaleX, q.Y*scaleY
+
+				xs, ys := circlePoints(aX, aY, radius)
+				raster = append(raster, newRasterShape(xs, ys, c))
+
+				if l := math.Hypot(bX-aX, bY-aY); l > 0 {
+					nX, nY := (aY-bY)/l*radius, (bX-aX)/l*radius
+					raster = append(raster, newRasterShape(
+						[]float64{aX + nX, bX + nX, bX - nX, aX - nX},
+						[]float64{aY + nY, bY + nY, bY - nY, aY - nY}, c))
+				}
+			}
+		}
+	}
+
+	if o.vertexRadius > 0 {
+		for _, v := range vertices(shapes, o.vertexColor) {
+			xs, ys := circlePoints(v.point.X*scaleX, v.point.Y*scaleY, o.vertexRadius*float64(s))
+			raster = append(raster, newRasterShape(xs, ys, v.color))
+		}
+	}
+
+	// The shapes which overlap each band, in order
+	bands := make([][]int, numBands)
+
+	for i, r := range raster {
+		first := int(math.Max(0, math.Floor(r.minY/bandSize)))
+		last := int(math.Min(float64(numBands-1), math.Floor(r.maxY/bandSize)))
+
+		for b := first; b <= last; b++ {
+			bands[b] = append(bands[b], i)
+		}
+	}
+
+	var background [4]float64
+	if o.background != nil {
+		background = [4]float64{o.background.R, o.background.G, o.background.B, 1}
+	}
+
+	cols := w * s
+	owners := make([]int32, cols*rasterBand*s) // The index of the shape covering each sample, or -1
+	var crossings []float64
+
+	for b := 0; b < numBands; b++ {
+		y0 := b * rasterBand
+		rows := min(rasterBand, h-y0)
+
+		for i := range owners {
+			owners[i] = -1
+		}
+
+		for _, i := range bands[b] {
+			r := raster[i]
+
+			// The rows of samples whose centers are within the shape and the band
+			first := int(math.Max(math.Ceil(r.minY-0.5), float64(y0*s)))
+			last := int(math.Min(math.Ceil(r.maxY-0.5), float64((y0+rows)*s)))
+
+			for y := first; y < last; y++ {
+				center := float64(y) + 0.5
+				crossings = crossings[:0]
+
+				for j, aY := range r.ys {
+					k := (j + 1) % len(r.ys)
+					bY := r.ys[k]
+
+					if (aY <= center) != (bY <= center) {
+						t := (center - aY) / (bY - aY)
+						crossings = append(crossings, r.xs[j]+t*(r.xs[k]-r.xs[j]))
+					}
+				}
+
+				sort.Float64s(crossings)
+
+				row := owners[(y-y0*s)*cols : (y-y0*s+1)*cols]
+
+				// The samples whose centers are between each pair of crossings are inside (the even-odd rule)
+				for j := 1; j < len(crossings); j += 2 {
+					x0 := int(math.Max(0, math.Ceil(crossings[j-1]-0.5)))
+					x1 := int(math.Min(float64(cols), math.Ceil(crossings[j]-0.5)))
+
+					for x := x0; x < x1; x++ {
+						row[x] = int32(i)
+					}
+				}
+			}
+		}
+
+		// Average the samples of each pixel
+		count := float64(s * s)
+
+		for y := 0; y < rows; y++ {
+			for x := 0; x < w; x++ {
+				i := dst.PixOffset(bounds.Min.X+x, bounds.Min.Y+y0+y)
+				pix := dst.Pix[i : i+4 : i+4]
+
+				base := background
+				if o.background == nil {
+					base = [4]float64{float64(pix[0]) / 255, float64(pix[1]) / 255, float64(pix[2]) / 255, float64(pix[3]) / 255}
+				}
+
+				var sum [4]float64
+
+				for sY := 0; sY < s; sY++ {
+					row := owners[(y*s+sY)*cols+x*s : (y*s+sY)*cols+(x+1)*s]
+
+					for _, owner := range row {
+						c := &base
+						if owner != -1 {
+							c = &raster[owner].color
+						}
+
+						sum[0] += c[0]
+						sum[1] += c[1]
+						sum[2] += c[2]
+						sum[3] += c[3]
+					}
+				}
+
+				for c := range sum {
+					pix[c] = to8Bit(sum[c] / count)
+				}
+			}
+		}
+	}
+}
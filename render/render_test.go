@@ -1,10 +1,21 @@
 package render
 
 import (
+	"bytes"
+	"compress/zlib"
+	"github.com/RH12503/Triangula/color"
 	"github.com/RH12503/Triangula/geom"
 	"github.com/RH12503/Triangula/image"
 	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/rasterize"
 	"github.com/stretchr/testify/assert"
+	stdimage "image"
+	stdcolor "image/color"
+	"image/draw"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +28,264 @@ func TestTrianglesOnImage(t *testing.T) {
 		Triangle: normgeom.NewNormTriangle(0.12, 0.32, 0.65, 0.43, 0.23, 0.87),
 	})
 }
+
+func TestTrianglesOnImagePalette(t *testing.T) {
+	palette := color.Palette{{R: 1, G: 1, B: 1}, {R: 0.1, G: 0, B: 0}}
+
+	data, indexes := TrianglesOnImagePalette([]geom.Triangle{
+		geom.NewTriangle(12, 32, 65, 43, 23, 87),
+	}, image.NewData(100, 100), palette, false)
+
+	assert.Equal(t, indexes, []int{1})
+	assert.Equal(t, data[0].Color, palette[1])
+
+	// Matched by luminance, black is closer to blue than to dark red
+	palette = color.Palette{{R: 0.3}, {B: 0.5}}
+	_, indexes = TrianglesOnImagePalette([]geom.Triangle{
+		geom.NewTriangle(12, 32, 65, 43, 23, 87),
+	}, image.NewData(100, 100), palette, true)
+
+	assert.Equal(t, indexes, []int{1})
+}
+
+func TestTrianglesOnImageTone(t *testing.T) {
+	red := stdimage.NewUniform(stdcolor.RGBA{R: 255, A: 255})
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), red, stdimage.Point{}, draw.Src)
+
+	tri := []geom.Triangle{geom.NewTriangle(12, 32, 65, 43, 23, 87)}
+
+	gray := TrianglesOnImageTone(tri, image.ToData(img), color.Gray)
+	assert.InDelta(t, gray[0].Color.R, 0.299, 1e-9)
+	assert.Equal(t, gray[0].Color.R, gray[0].Color.B)
+
+	duotone := TrianglesOnImageTone(tri, image.ToData(img), color.Duotone(color.RGB{B: 1}, color.RGB{R: 1}))
+	assert.InDelta(t, duotone[0].Color.R, 0.299, 1e-9)
+	assert.InDelta(t, duotone[0].Color.B, 1-0.299, 1e-9)
+}
+
+func TestMergePolygons(t *testing.T) {
+	red := color.RGB{R: 1}
+	blue := color.RGB{B: 1}
+
+	// A 3x3 grid of squares, where the middle square is blue and the others are red or nearly red
+	var polygons []geom.Polygon
+	var data []PolygonData
+
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			polygons = append(polygons, geom.Polygon{Points: []geom.Point{
+				{X: x * 10, Y: y * 10}, {X: x*10 + 10, Y: y * 10}, {X: x*10 + 10, Y: y*10 + 10}, {X: x * 10, Y: y*10 + 10},
+			}})
+
+			c := red
+			if x == 1 && y == 1 {
+				c = blue
+			} else if x == 2 {
+				c = color.RGB{R: 0.98, G: 0.02}
+			}
+			data = append(data, PolygonData{Color: c})
+		}
+	}
+
+	merged := MergePolygons(polygons, data, 30, 30, 0.05)
+	assert.Equal(t, len(merged), 2)
+
+	// The red polygon surrounds the blue one, so it's first
+	assert.Equal(t, merged[0].Polygon, normgeom.NormPolygon{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}})
+	assert.InDelta(t, merged[0].Color.R, 0.9925, 1e-9)
+	assert.Equal(t, merged[1].Color, blue)
+
+	// Drawing the polygons in order covers every pixel with the right color
+	pixels := map[geom.Point]color.RGB{}
+	for _, m := range merged {
+		var polygon geom.Polygon
+		for _, p := range m.Polygon.Points {
+			polygon.Points = append(polygon.Points, geom.Point{X: int(p.X * 30), Y: int(p.Y * 30)})
+		}
+		rasterize.ScanlinePolygon(polygon, func(x, y int) {
+			pixels[geom.Point{X: x, Y: y}] = m.Color
+		})
+	}
+	assert.Equal(t, len(pixels), 900)
+	assert.Equal(t, pixels[geom.Point{X: 15, Y: 15}], blue)
+	assert.Equal(t, pixels[geom.Point{X: 5, Y: 25}], merged[0].Color)
+
+	// Nothing is merged with a tolerance of 0 except polygons with the same color
+	merged = MergePolygons(polygons, data, 30, 30, 0)
+	assert.Equal(t, len(merged), 3)
+}
+
+func TestMergePolygons_TJunction(t *testing.T) {
+	red := color.RGB{R: 1}
+
+	// A square next to two rectangles, which meet in the middle of the square's right edge
+	polygons := []geom.Polygon{
+		{Points: []geom.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}},
+		{Points: []geom.Point{{X: 10, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 5}, {X: 10, Y: 5}}},
+		{Points: []geom.Point{{X: 10, Y: 5}, {X: 20, Y: 5}, {X: 20, Y: 10}, {X: 10, Y: 10}}},
+	}
+	data := []PolygonData{{Color: red}, {Color: red}, {Color: red}}
+
+	merged := MergePolygons(polygons, data, 20, 10, 0.05)
+	assert.Equal(t, len(merged), 1)
+	assert.Equal(t, merged[0].Polygon, normgeom.NormPolygon{Points: []normgeom.NormPoint{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1},
+	}})
+}
+
+func TestWriteSVG(t *testing.T) {
+	red := color.RGB{R: 1}
+	shapes := []Shape{
+		{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, Color: red},
+		{Points: []normgeom.NormPoint{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}, Color: color.RGB{B: 1}},
+		{Points: []normgeom.NormPoint{{X: 0.333, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}, Color: red},
+	}
+
+	var b strings.Builder
+	assert.NoError(t, WriteSVG(&b, shapes, 300, 200, WithBackground(color.RGB{R: 1, G: 1, B: 1})))
+
+	svg := b.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="300" height="200" viewBox="0 0 300 200">`))
+	assert.Equal(t, strings.Count(svg, "<polygon"), 3)
+	assert.Contains(t, svg, `<rect width="300" height="200" fill="#ffffff"/>`)
+	assert.Contains(t, svg, `<polygon points="0,0 300,0 0,200" fill="#ff0000"/>`)
+	assert.Contains(t, svg, `points="99.9,0 300,200 0,200"`)
+
+	b.Reset()
+	assert.NoError(t, WriteSVG(&b, shapes, 300, 200, WithMerge(), WithPrecision(0),
+		WithStroke(0.5, SolidColor(color.RGB{}))))
+
+	svg = b.String()
+	assert.Equal(t, strings.Count(svg, "<path"), 2)
+	assert.Contains(t, svg, `<path d="M0,0L300,0L0,200Z M100,0L300,200L0,200Z" fill="#ff0000" stroke="#000000" stroke-width="0.5"`)
+}
+
+func TestDrawShapes(t *testing.T) {
+	red := color.RGB{R: 1}
+	blue := color.RGB{B: 1}
+
+	// Two triangles sharing a diagonal edge
+	shapes := []Shape{
+		{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, Color: red},
+		{Points: []normgeom.NormPoint{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}, Color: red},
+	}
+
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 37, 23))
+	DrawShapes(img, shapes)
+
+	for y := 0; y < 23; y++ {
+		for x := 0; x < 37; x++ {
+			assert.Equal(t, img.RGBAAt(x, y), stdcolor.RGBA{R: 255, A: 255})
+		}
+	}
+
+	// A shape drawn on top of half the image
+	shapes = append(shapes, Shape{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 0.5, Y: 1}, {X: 0, Y: 1}}, Color: blue})
+
+	img = stdimage.NewRGBA(stdimage.Rect(0, 0, 40, 40))
+	DrawShapes(img, shapes, WithSamples(2))
+
+	assert.Equal(t, img.RGBAAt(19, 5), stdcolor.RGBA{B: 255, A: 255})
+	assert.Equal(t, img.RGBAAt(20, 5), stdcolor.RGBA{R: 255, A: 255})
+
+	// A triangle covering half a pixel, anti-aliased over the background
+	img = stdimage.NewRGBA(stdimage.Rect(0, 0, 1, 1))
+	DrawShapes(img, shapes[:1], WithBackground(color.RGB{}))
+
+	c := img.RGBAAt(0, 0)
+	// Samples on the edge belong to the shape on their right
+	assert.InDelta(t, float64(c.R), 255*6./16, 1)
+	assert.Equal(t, c.A, uint8(255))
+}
+
+func TestWritePDF(t *testing.T) {
+	shapes := []Shape{
+		{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, Color: color.RGB{R: 1}},
+		{Points: []normgeom.NormPoint{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}, Color: color.RGB{B: 1}},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, WritePDF(&b, shapes, 100, 50, WithDPI(144), WithMargin(10)))
+
+	pdf := b.Bytes()
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.Contains(pdf, []byte("/MediaBox [0 0 70 45]")))
+
+	// The cross-reference table points to each object
+	xref, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(string(pdf))[1])
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n0 5\n")))
+
+	for i, offset := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(string(pdf[xref:]), -1) {
+		o, _ := strconv.Atoi(offset[1])
+		assert.True(t, bytes.HasPrefix(pdf[o:], []byte(strconv.Itoa(i+1)+" 0 obj")))
+	}
+
+	start := bytes.Index(pdf, []byte("stream\n")) + len("stream\n")
+	z, err := zlib.NewReader(bytes.NewReader(pdf[start:]))
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadAll(z)
+	assert.NoError(t, err)
+	assert.Equal(t, string(content), "1 0 0 rg\n10 35 m\n60 35 l\n10 10 l\nh\nf\n0 0 1 rg\n60 35 m\n60 10 l\n10 10 l\nh\nf\n")
+}
+
+func TestWriteEPS(t *testing.T) {
+	shapes := []Shape{
+		{Points: []normgeom.NormPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, Color: color.RGB{R: 1}},
+		{Points: []normgeom.NormPoint{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}, Color: color.RGB{R: 1}},
+	}
+
+	var b strings.Builder
+	assert.NoError(t, WriteEPS(&b, shapes, 100, 200, WithPage(PageSize{Width: 300, Height: 300}), WithMargin(50),
+		WithMerge(), WithStroke(1, SolidColor(color.RGB{}))))
+
+	eps := b.String()
+	assert.True(t, strings.HasPrefix(eps, "%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox: 0 0 300 300\n"))
+	assert.Contains(t, eps, "1 setlinewidth")
+
+	// The image is scaled to fit in the margins and centered
+	assert.Contains(t, eps, "1 0 0 rg\n100 250 m\n200 250 l\n100 50 l\nh\n200 250 m\n200 50 l\n100 50 l\nh\n0 0 0 b\n")
+	assert.True(t, strings.HasSuffix(eps, "showpage\n%%EOF\n"))
+}
+
+func TestEffects(t *testing.T) {
+	shapes := []Shape{
+		{Points: []normgeom.NormPoint{{X: 0.25, Y: 0.25}, {X: 0.75, Y: 0.25}, {X: 0.25, Y: 0.75}}, Color: color.RGB{R: 1}},
+		{Points: []normgeom.NormPoint{{X: 0.75, Y: 0.25}, {X: 0.75, Y: 0.75}, {X: 0.25, Y: 0.75}}, Color: color.RGB{B: 1}},
+	}
+
+	assert.Equal(t, Darken(0.5)(color.RGB{R: 1, G: 0.5}), color.RGB{R: 0.5, G: 0.25})
+	assert.Equal(t, Lighten(0.5)(color.RGB{R: 1, G: 0.5}), color.RGB{R: 1, G: 0.75, B: 0.5})
+
+	// Raster
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 40, 40))
+	DrawShapes(img, shapes, WithOutlineOnly(), WithStroke(2, SolidColor(color.RGB{G: 1})),
+		WithVertices(3, FillColor()))
+
+	assert.Equal(t, img.RGBAAt(14, 14), stdcolor.RGBA{})
+	assert.Equal(t, img.RGBAAt(20, 10), stdcolor.RGBA{G: 255, A: 255})
+	assert.Equal(t, img.RGBAAt(2, 2), stdcolor.RGBA{})
+
+	// The last shape with a vertex sets its color
+	assert.Equal(t, img.RGBAAt(10, 10), stdcolor.RGBA{R: 255, A: 255})
+	assert.Equal(t, img.RGBAAt(30, 10), stdcolor.RGBA{B: 255, A: 255})
+
+	// Vector
+	var b strings.Builder
+	assert.NoError(t, WriteSVG(&b, shapes, 40, 40, WithOutlineOnly(), WithVertices(1, Darken(1))))
+
+	svg := b.String()
+	assert.Equal(t, strings.Count(svg, `fill="none" stroke="#ff0000" stroke-width="1"`), 1)
+	assert.Equal(t, strings.Count(svg, "<circle"), 4)
+	assert.Contains(t, svg, `<circle cx="10" cy="10" r="1" fill="#000000"/>`)
+
+	b.Reset()
+	assert.NoError(t, WriteEPS(&b, shapes, 40, 40, WithOutlineOnly(), WithVertices(1, FillColor())))
+
+	eps := b.String()
+	assert.Equal(t, strings.Count(eps, " S\n"), 2)
+	assert.Equal(t, strings.Count(eps, " d\n"), 4)
+	assert.NotContains(t, eps, "\nf\n")
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"github.com/RH12503/Triangula/color"
+	"github.com/RH12503/Triangula/normgeom"
+)
+
+// Shape is a filled polygon with normalized coordinates, which triangles and polygons are converted to before
+// being written by the outputs of the package.
+type Shape struct {
+	Points []normgeom.NormPoint
+	Color  color.RGB
+}
+
+// TriangleShapes converts triangles to Shape's.
+func TriangleShapes(triangles []TriangleData) []Shape {
+	shapes := make([]Shape, len(triangles))
+	for i, t := range triangles {
+		shapes[i] = Shape{Points: []normgeom.NormPoint{t.Triangle.Points[0], t.Triangle.Points[1], t.Triangle.Points[2]}, Color: t.Color}
+	}
+	return shapes
+}
+
+// PolygonShapes converts polygons to Shape's.
+func PolygonShapes(polygons []PolygonData) []Shape {
+	shapes := make([]Shape, len(polygons))
+	for i, p := range polygons {
+		shapes[i] = Shape{Points: p.Polygon.Points, Color: p.Color}
+	}
+	return shapes
+}
+
+// groupByColor groups shapes with the same color, keeping the order of each color's first shape.
+func groupByColor(shapes []Shape) [][]Shape {
+	var groups [][]Shape
+	indexes := map[color.RGB]int{}
+
+	for _, s := range shapes {
+		i, ok := indexes[s.Color]
+		if !ok {
+			i = len(groups)
+			indexes[s.Color] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], s)
+	}
+
+	return groups
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"io"
+	"strings"
+)
+
+// WriteSVG writes shapes (see TriangleShapes and PolygonShapes) as an SVG image with a width and height.
+// WithBackground, WithStroke, WithPrecision, WithMerge and the effects of WithVertices and WithOutlineOnly
+// configure the image. Merging shapes changes the order they're drawn in, so it shouldn't be used when shapes
+// overlap (such as the output of MergePolygons).
+func WriteSVG(w io.Writer, shapes []Shape, width, height int, opts ...Option) error {
+	o := newOptions(opts)
+	out := &errWriter{w: w}
+
+	out.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n",
+		width, height, width, height)
+
+	if o.background != nil {
+		out.printf(`<rect width="%v" height="%v" fill="%v"/>`+"\n", width, height, hexColor(*o.background))
+	}
+
+	if o.merge {
+		for _, group := range groupByColor(shapes) {
+			var d strings.Builder
+			for _, s := range group {
+				if len(s.Points) < 3 {
+					continue
+				}
+				if d.Len() > 0 {
+					d.WriteByte(' ')
+				}
+				for i, p := range s.Points {
+					if i == 0 {
+						d.WriteByte('M')
+					} else {
+						d.WriteByte('L')
+					}
+					d.WriteString(o.svgPoint(p.X*float64(width), p.Y*float64(height)))
+				}
+				d.WriteByte('Z')
+			}
+
+			if d.Len() > 0 {
+				out.printf(`<path d="%v"%v/>`+"\n", d.String(), o.svgPaint(group[0]))
+			}
+		}
+	} else {
+		for _, s := range shapes {
+			if len(s.Points) < 3 {
+				continue
+			}
+
+			points := make([]string, len(s.Points))
+			for i, p := range s.Points {
+				points[i] = o.svgPoint(p.X*float64(width), p.Y*float64(height))
+			}
+
+			out.printf(`<polygon points="%v"%v/>`+"\n", strings.Join(points, " "), o.svgPaint(s))
+		}
+	}
+
+	if o.vertexRadius > 0 {
+		r := formatFloat(o.vertexRadius, -1)
+		for _, v := range vertices(shapes, o.vertexColor) {
+			out.printf(`<circle cx="%v" cy="%v" r="%v" fill="%v"/>`+"\n",
+				formatFloat(v.point.X*float64(width), o.precision), formatFloat(v.point.Y*float64(height), o.precision),
+				r, hexColor(v.color))
+		}
+	}
+
+	out.printf("</svg>\n")
+
+	return out.err
+}
+
+// svgPoint formats the coordinates of a point.
+func (o *options) svgPoint(x, y float64) string {
+	return formatFloat(x, o.precision) + "," + formatFloat(y, o.precision)
+}
+
+// svgPaint returns the attributes which fill and stroke a shape.
+func (o *options) svgPaint(s Shape) string {
+	attrs := ` fill="none"`
+	if !o.outlineOnly {
+		attrs = ` fill="` + hexColor(s.Color) + `"`
+	}
+
+	if o.strokeWidth > 0 {
+		attrs += ` stroke="` + hexColor(o.strokeColor(s.Color)) + `" stroke-width="` +
+			formatFloat(o.strokeWidth, -1) + `" stroke-linejoin="round"`
+	}
+
+	return attrs
+}
@@ -48,3 +48,31 @@ func TrianglesOnImage(triangles []geom.Triangle, image image.Data) []TriangleDat
 
 	return triangleData
 }
+
+// TrianglesOnImagePalette calculates the color of each triangle like TrianglesOnImage, but only using colors
+// from a palette. The index in the palette of each triangle's color is returned alongside the triangles.
+// If grayscale is true, colors are matched by their luminance, like fitness functions using fitness.WithGrayscale.
+func TrianglesOnImagePalette(triangles []geom.Triangle, image image.Data, palette color.Palette, grayscale bool) ([]TriangleData, []int) {
+	triangleData := TrianglesOnImage(triangles, image)
+	indexes := make([]int, len(triangleData))
+
+	for i := range triangleData {
+		indexes[i] = palette.Match(triangleData[i].Color, grayscale)
+		triangleData[i].Color = palette[indexes[i]]
+	}
+
+	return triangleData, indexes
+}
+
+// TrianglesOnImageTone calculates the average luminance of each triangle, and colors the triangles by mapping
+// their luminance with a color.ToneMap (such as color.Gray or color.Duotone).
+func TrianglesOnImageTone(triangles []geom.Triangle, image image.Data, tone color.ToneMap) []TriangleData {
+	triangleData := TrianglesOnImage(triangles, image)
+
+	for i := range triangleData {
+		// The luminance is linear, so the luminance of the average color is the average luminance
+		triangleData[i].Color = tone(color.Luminance(triangleData[i].Color))
+	}
+
+	return triangleData
+}
@@ -0,0 +1,45 @@
+package render
+
+import (
+	"fmt"
+	"github.com/RH12503/Triangula/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// errWriter writes formatted text to an io.Writer, keeping the first error so it only needs to be checked
+// once everything has been written.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, a ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, a...)
+	}
+}
+
+// formatFloat formats a number with at most a number of decimal places, without trailing zeros.
+func formatFloat(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// to8Bit converts a color value between 0 and 1 to a value between 0 and 255.
+func to8Bit(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(v, 1)) * 255))
+}
+
+// hexColor returns a color in the #rrggbb format.
+func hexColor(c color.RGB) string {
+	return fmt.Sprintf("#%02x%02x%02x", to8Bit(c.R), to8Bit(c.G), to8Bit(c.B))
+}
@@ -0,0 +1,347 @@
+package incrdelaunay
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidConstraint is returned when a constraint's endpoints are the same point.
+	ErrInvalidConstraint = errors.New("incrdelaunay: constraint endpoints are identical")
+	// ErrConstraintsIntersect is returned when a constraint would cross another constraint.
+	ErrConstraintsIntersect = errors.New("incrdelaunay: constraints intersect")
+)
+
+// AddConstraint adds a segment from a to b which is always an edge of the triangulation (split into several
+// edges if other points lie on it), making it a constrained Delaunay triangulation.
+// The endpoints are inserted into the triangulation and can't be removed, while other points can still be
+// inserted and removed, including points on the segment. Constraints may share endpoints but can't cross.
+func (d *Delaunay) AddConstraint(a, b Point) error {
+	if a == b {
+		return fmt.Errorf("%w: %v", ErrInvalidConstraint, a)
+	}
+
+	for _, c := range d.constraints {
+		if segmentsCross(a, b, c.A, c.B) {
+			return fmt.Errorf("%w: %v-%v crosses %v-%v", ErrConstraintsIntersect, a, b, c.A, c.B)
+		}
+	}
+
+	// The endpoints are inserted as extra copies, so they're never removed when
+	// other copies of the same points are removed
+	d.Insert(a)
+	d.Insert(b)
+
+	d.constraints = append(d.constraints, NewEdge(a, b))
+
+	d.enforceConstraint(a, b)
+
+	return nil
+}
+
+// Constraints returns the constraint segments of the triangulation. The slice must not be modified.
+func (d Delaunay) Constraints() []Edge {
+	return d.constraints
+}
+
+// IsConstrained returns if the edge from a to b lies on a constraint, meaning it can't be removed
+// from the triangulation.
+func (d Delaunay) IsConstrained(a, b Point) bool {
+	for _, c := range d.constraints {
+		if onSegment(a, c.A, c.B) && onSegment(b, c.A, c.B) {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintThrough returns a constraint which point p lies inside of (excluding its endpoints).
+func (d Delaunay) constraintThrough(p Point) (Edge, bool) {
+	for _, c := range d.constraints {
+		if p != c.A && p != c.B && onSegment(p, c.A, c.B) {
+			return c, true
+		}
+	}
+	return Edge{}, false
+}
+
+// enforceConstraint makes the segment from a to b an edge of the triangulation, by removing the
+// triangles it crosses and retriangulating the polygons on either side of it.
+func (d *Delaunay) enforceConstraint(a, b Point) {
+	// If the segment passes through other points, enforce each part of it separately
+	for _, t := range d.triangles {
+		if t.A.X == -1 {
+			continue
+		}
+		for _, v := range [3]Point{t.A, t.B, t.C} {
+			if v != a && v != b && onSegment(v, a, b) {
+				d.enforceConstraint(a, v)
+				d.enforceConstraint(v, b)
+				return
+			}
+		}
+	}
+
+	// Find the triangles crossed by the segment
+	d.cavity = d.cavity[:0]
+
+	for i, t := range d.triangles {
+		if t.A.X == -1 {
+			continue
+		}
+		if t.HasVertex(a) && t.HasVertex(b) {
+			// The segment is already an edge
+			return
+		}
+		if segmentsCross(a, b, t.A, t.B) || segmentsCross(a, b, t.B, t.C) || segmentsCross(a, b, t.C, t.A) {
+			d.cavity = append(d.cavity, uint32(i))
+		}
+	}
+
+	// Walk along the segment from a to b through the crossed triangles, creating the chains of points
+	// on the left and right of the segment in order
+	var left, right []Point
+	var u, v Point  // The edge currently crossed, with u on the left and v on the right.
+	var prev uint32 // The triangle the walk came from.
+
+	for _, i := range d.cavity {
+		t := d.triangles[i]
+		if t.HasVertex(a) {
+			u, v = t.otherVertices(a)
+			prev = i
+			if segmentsCross(a, b, u, v) {
+				break
+			}
+		}
+	}
+
+	for {
+		if orient(a, b, u) < 0 {
+			u, v = v, u
+		}
+		left = append(left, u)
+		right = append(right, v)
+
+		var w Point
+		w, prev = d.cavityOpposite(u, v, prev)
+		if w == b {
+			break
+		}
+
+		if orient(a, b, w) > 0 {
+			u = w
+		} else {
+			v = w
+		}
+	}
+
+	for _, i := range d.cavity {
+		d.grid.RemoveTriangle(d.triangles[i], i)
+		d.markFreeTriangle(i)
+	}
+
+	d.triangulatePseudoPolygon(a, b, dedupe(left))
+	d.triangulatePseudoPolygon(a, b, dedupe(right))
+}
+
+// cavityOpposite returns the crossed triangle other than prev which contains edge uv, and its vertex opposite of the edge.
+func (d *Delaunay) cavityOpposite(u, v Point, prev uint32) (Point, uint32) {
+	for _, i := range d.cavity {
+		t := d.triangles[i]
+		if i == prev || !t.HasVertex(u) || !t.HasVertex(v) {
+			continue
+		}
+
+		w := t.A
+		if w == u || w == v {
+			w = t.B
+			if w == u || w == v {
+				w = t.C
+			}
+		}
+
+		return w, i
+	}
+
+	panic("incrdelaunay: constraint walk left the crossed triangles")
+}
+
+// triangulatePseudoPolygon adds the constrained Delaunay triangulation of the polygon made of edge ab and a
+// chain of points from a to b on one side of it.
+// See Anglada, "An improved incremental algorithm for constructing restricted Delaunay triangulations".
+func (d *Delaunay) triangulatePseudoPolygon(a, b Point, chain []Point) {
+	if len(chain) == 0 {
+		return
+	}
+
+	// Find the point whose circumcircle with the edge contains no other points of the chain
+	ci := 0
+	for i := 1; i < len(chain); i++ {
+		c := chain[ci]
+		v := chain[i]
+		if inCircle(int64(a.X), int64(a.Y), int64(b.X), int64(b.Y), int64(c.X), int64(c.Y), int64(v.X), int64(v.Y)) > 0 {
+			ci = i
+		}
+	}
+
+	c := chain[ci]
+	d.triangulatePseudoPolygon(a, c, chain[:ci])
+	d.triangulatePseudoPolygon(c, b, chain[ci+1:])
+
+	d.addTriangle(NewTriangle(a, b, c))
+}
+
+// insertConstrained removes the triangles whose circumcircles contain point p and which are visible from p
+// without crossing a constraint, adding their edges to the edges.
+func (d *Delaunay) insertConstrained(p Point) {
+	d.candidates = d.candidates[:0]
+	d.grid.IterCircumcirclesThatContain(p, d.triangles, func(i uint32) {
+		d.candidates = append(d.candidates, i)
+	})
+
+	// Start from the triangle containing the point
+	d.cavity = d.cavity[:0]
+	for _, i := range d.candidates {
+		if d.triangles[i].contains(p) {
+			d.cavity = append(d.cavity, i)
+			break
+		}
+	}
+
+	// Flood fill through the edges of the triangles which aren't constrained
+	for n := 0; n < len(d.cavity); n++ {
+		t := d.triangles[d.cavity[n]]
+
+		for _, e := range [3]Edge{{t.A, t.B}, {t.B, t.C}, {t.C, t.A}} {
+			// The constraint is split when the point lies on it, so it can be crossed
+			if d.IsConstrained(e.A, e.B) && !onSegment(p, e.A, e.B) {
+				continue
+			}
+
+			for _, i := range d.candidates {
+				other := d.triangles[i]
+				if other.HasVertex(e.A) && other.HasVertex(e.B) && !containsIndex(d.cavity, i) {
+					d.cavity = append(d.cavity, i)
+				}
+			}
+		}
+	}
+
+	for _, i := range d.cavity {
+		t := d.triangles[i]
+
+		d.addEdge(NewEdge(t.A, t.B))
+		d.addEdge(NewEdge(t.B, t.C))
+		d.addEdge(NewEdge(t.C, t.A))
+
+		d.grid.RemoveTriangle(t, i)
+		d.markFreeTriangle(i)
+	}
+}
+
+// removeFromConstraint retriangulates the hole left by removing point p which lies inside constraint c.
+// The hull is split into the two sides of the constraint, which are triangulated separately so the
+// constraint remains an edge.
+func (d *Delaunay) removeFromConstraint(p Point, c Edge) {
+	// Find the neighbors of the point along the constraint
+	u, v := -1, -1
+	for i, h := range d.hull {
+		if onSegment(h, c.A, c.B) {
+			if onSegment(h, p, c.A) {
+				u = i
+			} else {
+				v = i
+			}
+		}
+	}
+
+	var chain []Point
+	for i := (u + 1) % len(d.hull); i != v; i = (i + 1) % len(d.hull) {
+		chain = append(chain, d.hull[i])
+	}
+	d.triangulatePseudoPolygon(d.hull[u], d.hull[v], chain)
+
+	chain = chain[:0]
+	for i := (v + 1) % len(d.hull); i != u; i = (i + 1) % len(d.hull) {
+		chain = append(chain, d.hull[i])
+	}
+	d.triangulatePseudoPolygon(d.hull[v], d.hull[u], chain)
+}
+
+// otherVertices returns the two vertices of a triangle other than p.
+func (t Triangle) otherVertices(p Point) (Point, Point) {
+	switch p {
+	case t.A:
+		return t.B, t.C
+	case t.B:
+		return t.C, t.A
+	}
+	return t.A, t.B
+}
+
+// contains returns if point p is inside the triangle or on its edges.
+func (t Triangle) contains(p Point) bool {
+	o1 := orient(t.A, t.B, p)
+	o2 := orient(t.B, t.C, p)
+	o3 := orient(t.C, t.A, p)
+
+	return (o1 >= 0 && o2 >= 0 && o3 >= 0) || (o1 <= 0 && o2 <= 0 && o3 <= 0)
+}
+
+// orient returns a positive value if c is to the left of the line from a to b, a negative value
+// if it's to the right, and zero if the points are collinear.
+func orient(a, b, c Point) int64 {
+	return int64(b.X-a.X)*int64(c.Y-a.Y) - int64(b.Y-a.Y)*int64(c.X-a.X)
+}
+
+// onSegment returns if point p lies on the segment from a to b, including its endpoints.
+func onSegment(p, a, b Point) bool {
+	if orient(a, b, p) != 0 {
+		return false
+	}
+	return min32(a.X, b.X) <= p.X && p.X <= max32(a.X, b.X) &&
+		min32(a.Y, b.Y) <= p.Y && p.Y <= max32(a.Y, b.Y)
+}
+
+// segmentsCross returns if the segments ab and cd cross at a single point inside both of them.
+func segmentsCross(a, b, c, d Point) bool {
+	o1, o2 := orient(a, b, c), orient(a, b, d)
+	o3, o4 := orient(c, d, a), orient(c, d, b)
+
+	return ((o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)) &&
+		((o3 > 0 && o4 < 0) || (o3 < 0 && o4 > 0))
+}
+
+// dedupe removes consecutive duplicate points.
+func dedupe(points []Point) []Point {
+	out := points[:0]
+	for _, p := range points {
+		if len(out) == 0 || out[len(out)-1] != p {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func containsIndex(indexes []uint32, i uint32) bool {
+	for _, v := range indexes {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
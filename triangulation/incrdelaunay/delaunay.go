@@ -15,7 +15,7 @@ type Delaunay struct {
 
 	pointMap pointMap
 
-	freeTriangles []uint16 // A list of free indexes in the triangles slice.
+	freeTriangles []uint32 // A list of free indexes in the triangles slice.
 
 	superTriangle Triangle // A triangle that contains all points added.
 
@@ -25,12 +25,21 @@ type Delaunay struct {
 
 	ears []ear // For performance purposes.
 
+	constraints []Edge // Segments which must be edges of the triangulation.
+
+	candidates, cavity []uint32 // For performance purposes.
+
 	numPoints    int // The number of points in the triangulation (including duplicate points).
 	uniquePoints int
 }
 
 // NewDelaunay returns a new Delaunay triangulation.
+// It panics if the size isn't supported, which can be checked beforehand with CheckSize.
 func NewDelaunay(w, h int) *Delaunay {
+	if err := CheckSize(w, h); err != nil {
+		panic(err)
+	}
+
 	delaunay := Delaunay{}
 
 	superTriangle := NewSuperTriangle(w, h)
@@ -56,16 +65,21 @@ func (d *Delaunay) Insert(p Point) bool {
 
 	d.resetEdges()
 
-	// Iterate through all the triangles with circumcircles that contain the point
-	d.grid.RemoveCircumcirclesThatContain(p, d.triangles, func(i uint16) {
-		t := d.triangles[i]
+	if len(d.constraints) > 0 {
+		// Triangles hidden behind a constraint are kept
+		d.insertConstrained(p)
+	} else {
+		// Iterate through all the triangles with circumcircles that contain the point
+		d.grid.RemoveCircumcirclesThatContain(p, d.triangles, func(i uint32) {
+			t := d.triangles[i]
 
-		d.addEdge(NewEdge(t.A, t.B))
-		d.addEdge(NewEdge(t.B, t.C))
-		d.addEdge(NewEdge(t.C, t.A))
+			d.addEdge(NewEdge(t.A, t.B))
+			d.addEdge(NewEdge(t.B, t.C))
+			d.addEdge(NewEdge(t.C, t.A))
 
-		d.markFreeTriangle(i) // Remove the triangle
-	})
+			d.markFreeTriangle(i) // Remove the triangle
+		})
+	}
 
 	// Connect the vertices along the hole to the point
 	for _, e := range d.edges {
@@ -110,7 +124,7 @@ func (d *Delaunay) Remove(p Point) {
 	}
 
 	// Iterates through all the triangles that have a connection to point p
-	d.grid.RemoveThatHasVertex(p, d.triangles, func(i uint16) {
+	d.grid.RemoveThatHasVertex(p, d.triangles, func(i uint32) {
 		t := d.triangles[i]
 
 		addPoint(t.A)
@@ -149,6 +163,12 @@ func (d *Delaunay) Remove(p Point) {
 		panic("...")
 	})
 
+	// If the point splits a constraint, the constraint needs to be restored
+	if c, ok := d.constraintThrough(p); ok {
+		d.removeFromConstraint(p, c)
+		return
+	}
+
 	// Add the ears one by one based on its score
 	// using the algorithm described in: https://hal.inria.fr/inria-00167201/document.
 	// An ear is a triangle made by three consecutive points along the hull
@@ -243,14 +263,18 @@ func (d *Delaunay) addTriangle(t Triangle) {
 		return
 	}
 
-	d.grid.AddTriangle(t, uint16(len(d.triangles))) // Update the grid
+	if uint64(len(d.triangles)) >= MaxTriangles {
+		panic(ErrTooManyTriangles)
+	}
+
+	d.grid.AddTriangle(t, uint32(len(d.triangles))) // Update the grid
 
 	d.triangles = append(d.triangles, t)
 }
 
 // markFreeTriangle marks a triangle in the triangles slice as no longer being needed,
 // essentially removing the triangle from the triangulation.
-func (d *Delaunay) markFreeTriangle(i uint16) {
+func (d *Delaunay) markFreeTriangle(i uint32) {
 	d.triangles[i].A.X = -1 // Marks the triangle as invalid
 	d.freeTriangles = append(d.freeTriangles, i)
 }
@@ -263,14 +287,28 @@ func (d Delaunay) IterTriangles(triangle func(t Triangle)) {
 		}
 
 		// Exclude triangles that are connected to the superTriangle
-		if t.A != d.superTriangle.A && t.B != d.superTriangle.A && t.C != d.superTriangle.A &&
-			t.A != d.superTriangle.B && t.B != d.superTriangle.B && t.C != d.superTriangle.B &&
-			t.A != d.superTriangle.C && t.B != d.superTriangle.C && t.C != d.superTriangle.C {
+		if !d.connectedToSuper(t) {
 			triangle(t)
 		}
 	}
 }
 
+// IterVertexTriangles calls function triangle for each triangle with p as a vertex. Like IterTriangles,
+// triangles connected to the super triangle are excluded. Only the triangles near p are searched, so it's
+// much faster than IterTriangles for large triangulations.
+func (d Delaunay) IterVertexTriangles(p Point, triangle func(t Triangle)) {
+	d.grid.IterThatHasVertex(p, d.triangles, func(i uint32) {
+		if t := d.triangles[i]; t.A.X != -1 && !d.connectedToSuper(t) {
+			triangle(t)
+		}
+	})
+}
+
+// connectedToSuper returns if a triangle has a vertex of the super triangle.
+func (d Delaunay) connectedToSuper(t Triangle) bool {
+	return t.HasVertex(d.superTriangle.A) || t.HasVertex(d.superTriangle.B) || t.HasVertex(d.superTriangle.C)
+}
+
 // NumPoints returns the number of points in the triangulation, including duplicate points.
 func (d Delaunay) NumPoints() int {
 	return d.numPoints
@@ -292,13 +330,15 @@ func (d *Delaunay) Set(other *Delaunay) {
 	if len(d.freeTriangles) > len(other.freeTriangles) {
 		d.freeTriangles = d.freeTriangles[:len(other.freeTriangles)]
 	} else if len(d.freeTriangles) < len(other.freeTriangles) {
-		d.freeTriangles = make([]uint16, len(other.freeTriangles))
+		d.freeTriangles = make([]uint32, len(other.freeTriangles))
 	}
 
 	copy(d.freeTriangles, other.freeTriangles)
 
 	d.superTriangle = other.superTriangle
 
+	d.constraints = append(d.constraints[:0], other.constraints...)
+
 	d.grid.Set(&other.grid)
 	d.pointMap.Set(&other.pointMap)
 }
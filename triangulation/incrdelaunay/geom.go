@@ -1,9 +1,34 @@
 package incrdelaunay
 
 import (
+	"errors"
+	"fmt"
 	"math"
 )
 
+// MaxSize is the largest width or height a triangulation supports. Larger sizes would overflow
+// the integer arithmetic used to calculate circumcircles.
+const MaxSize = 1 << 17
+
+// MaxTriangles is the largest number of triangles (including removed ones waiting to be reused)
+// a triangulation can store.
+const MaxTriangles = math.MaxUint32
+
+var (
+	// ErrSizeTooLarge is returned when a triangulation is created with a width or height larger than MaxSize.
+	ErrSizeTooLarge = errors.New("incrdelaunay: size exceeds MaxSize")
+	// ErrTooManyTriangles is the panic value when a triangulation would need more than MaxTriangles triangles.
+	ErrTooManyTriangles = errors.New("incrdelaunay: number of triangles exceeds MaxTriangles")
+)
+
+// CheckSize returns an error if a triangulation can't be created with a width and height.
+func CheckSize(w, h int) error {
+	if w < 0 || h < 0 || w > MaxSize || h > MaxSize {
+		return fmt.Errorf("%w: %vx%v (max %v)", ErrSizeTooLarge, w, h, MaxSize)
+	}
+	return nil
+}
+
 // Triangle stores the vertices of a triangle as well as its circumcircle.
 type Triangle struct {
 	A, B, C      Point
@@ -27,12 +52,12 @@ func (t Triangle) HasVertex(p Point) bool {
 
 // NewSuperTriangle returns a Triangle large enough to cover all points within (0, 0) to (w, h).
 func NewSuperTriangle(w, h int) Triangle {
-	hW := int16(math.Ceil(float64(w) / 2))
-	hH := int16(math.Ceil(float64(h) / 2))
+	hW := int32(math.Ceil(float64(w) / 2))
+	hH := int32(math.Ceil(float64(h) / 2))
 
-	max := int16(w)
+	max := int32(w)
 	if h > w {
-		max = int16(h)
+		max = int32(h)
 	}
 	a := Point{hW - 2*max, hH - max}
 	b := Point{hW, hH + 2*max}
@@ -41,9 +66,9 @@ func NewSuperTriangle(w, h int) Triangle {
 	return NewTriangle(a, b, c)
 }
 
-// Point represents a 2D point, using int16 to optimize space.
+// Point represents a 2D point. Coordinates must be between -MaxSize and MaxSize.
 type Point struct {
-	X, Y int16
+	X, Y int32
 }
 
 // DistSq returns the distance squared to another point.
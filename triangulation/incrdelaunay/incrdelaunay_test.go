@@ -1,7 +1,10 @@
 package incrdelaunay
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -26,3 +29,282 @@ func TestDelaunay_Insert(t *testing.T) {
 		assert.Equal(t, tri.HasVertex(Point{32, 21}), true)
 	})
 }
+
+func TestDelaunay_InsertLarge(t *testing.T) {
+	// A triangulation beyond the range of int16 should be a scaled version of a small one
+	const scale = 100
+
+	points := []Point{{1, 2}, {450, 10}, {590, 390}, {300, 350}, {0, 399}}
+	small := NewDelaunay(600, 400)
+	large := NewDelaunay(600*scale, 400*scale)
+
+	for _, p := range points {
+		small.Insert(p)
+		large.Insert(Point{p.X * scale, p.Y * scale})
+	}
+
+	var expected, actual []Triangle
+	small.IterTriangles(func(tri Triangle) {
+		expected = append(expected, Triangle{
+			A: Point{tri.A.X * scale, tri.A.Y * scale},
+			B: Point{tri.B.X * scale, tri.B.Y * scale},
+			C: Point{tri.C.X * scale, tri.C.Y * scale},
+		})
+	})
+	large.IterTriangles(func(tri Triangle) {
+		actual = append(actual, Triangle{A: tri.A, B: tri.B, C: tri.C})
+	})
+
+	assert.Equal(t, len(actual), 3)
+	assert.Equal(t, actual, expected)
+
+	large.Remove(Point{300 * scale, 350 * scale})
+	large.IterTriangles(func(tri Triangle) {
+		assert.Equal(t, tri.HasVertex(Point{300 * scale, 350 * scale}), false)
+	})
+}
+
+func TestDelaunay_IterVertexTriangles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	delaunay := NewDelaunay(200, 100)
+	var points []Point
+	for i := 0; i < 100; i++ {
+		p := Point{X: int32(rng.Intn(201)), Y: int32(rng.Intn(101))}
+		points = append(points, p)
+		delaunay.Insert(p)
+	}
+
+	// The triangles around each point are the same as when searching all the triangles
+	for _, p := range points[:20] {
+		var expected, actual []Triangle
+		delaunay.IterTriangles(func(tri Triangle) {
+			if tri.HasVertex(p) {
+				expected = append(expected, tri)
+			}
+		})
+		delaunay.IterVertexTriangles(p, func(tri Triangle) {
+			actual = append(actual, tri)
+		})
+
+		assert.ElementsMatch(t, actual, expected)
+	}
+
+	delaunay.Remove(points[0])
+	delaunay.IterVertexTriangles(points[0], func(tri Triangle) {
+		t.Errorf("removed point has triangle %v", tri)
+	})
+}
+
+func TestCheckSize(t *testing.T) {
+	assert.Nil(t, CheckSize(MaxSize, 100))
+	assert.True(t, errors.Is(CheckSize(MaxSize+1, 100), ErrSizeTooLarge))
+	assert.Panics(t, func() {
+		NewDelaunay(100, MaxSize+1)
+	})
+}
+
+func TestDelaunay_AddConstraint(t *testing.T) {
+	const w, h = 200, 200
+
+	rng := rand.New(rand.NewSource(1))
+	delaunay := NewDelaunay(w, h)
+
+	var points []Point
+	for i := 0; i < 100; i++ {
+		p := Point{int32(rng.Intn(w)), int32(rng.Intn(h))}
+		points = append(points, p)
+		delaunay.Insert(p)
+	}
+
+	assert.Nil(t, delaunay.AddConstraint(Point{10, 20}, Point{190, 150}))
+	assert.Nil(t, delaunay.AddConstraint(Point{10, 20}, Point{30, 190}))
+	assert.True(t, errors.Is(delaunay.AddConstraint(Point{0, 100}, Point{200, 100}), ErrConstraintsIntersect))
+	assert.True(t, errors.Is(delaunay.AddConstraint(Point{5, 5}, Point{5, 5}), ErrInvalidConstraint))
+	checkConstrained(t, delaunay)
+
+	// Points on the constraint, and the triangulation after removing them, should still respect it
+	onConstraint := []Point{{100, 85}, {28, 33}, {154, 124}}
+	for _, p := range onConstraint {
+		delaunay.Insert(p)
+	}
+	checkConstrained(t, delaunay)
+
+	for _, p := range append(points, onConstraint...) {
+		delaunay.Remove(p)
+	}
+	checkConstrained(t, delaunay)
+
+	// The endpoints of the constraints can't be removed
+	assert.True(t, delaunay.HasPoint(Point{10, 20}))
+	assert.True(t, delaunay.IsConstrained(Point{10, 20}, Point{190, 150}))
+
+	other := NewDelaunay(w, h)
+	other.Set(delaunay)
+	for i := 0; i < 50; i++ {
+		other.Insert(Point{int32(rng.Intn(w)), int32(rng.Intn(h))})
+	}
+	assert.Equal(t, len(other.Constraints()), 2)
+	checkConstrained(t, other)
+}
+
+// checkConstrained checks that no edges of a triangulation cross a constraint, and that the triangulation
+// is constrained Delaunay: the circumcircle of each triangle contains no vertices visible from inside the triangle.
+func checkConstrained(t *testing.T, d *Delaunay) {
+	var triangles []Triangle
+	var area int64
+	for _, tri := range d.triangles {
+		if tri.A.X != -1 {
+			triangles = append(triangles, tri)
+			area += abs64(orient(tri.A, tri.B, tri.C))
+		}
+	}
+
+	// The triangles should exactly cover the super triangle
+	super := d.superTriangle
+	assert.Equal(t, area, abs64(orient(super.A, super.B, super.C)))
+
+	for _, tri := range triangles {
+		for _, c := range d.Constraints() {
+			assert.False(t, segmentsCross(tri.A, tri.B, c.A, c.B) || segmentsCross(tri.B, tri.C, c.A, c.B) ||
+				segmentsCross(tri.C, tri.A, c.A, c.B), "%v crosses %v", tri, c)
+		}
+	}
+
+	visible := func(a, b Point) bool {
+		for _, c := range d.Constraints() {
+			if segmentsCross(a, b, c.A, c.B) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, tri := range triangles {
+		for _, other := range triangles {
+			for _, p := range [3]Point{other.A, other.B, other.C} {
+				if tri.HasVertex(p) || inCircle(int64(tri.A.X), int64(tri.A.Y), int64(tri.B.X), int64(tri.B.Y),
+					int64(tri.C.X), int64(tri.C.Y), int64(p.X), int64(p.Y)) <= 0 {
+					continue
+				}
+				assert.False(t, visible(tri.A, p) && visible(tri.B, p) && visible(tri.C, p),
+					"%v is inside the circumcircle of %v", p, tri)
+			}
+		}
+	}
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestDelaunay_Mesh(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	delaunay := NewDelaunay(100, 100)
+	for i := 0; i < 50; i++ {
+		delaunay.Insert(Point{int32(rng.Intn(100)), int32(rng.Intn(100))})
+	}
+
+	mesh := delaunay.Mesh()
+
+	n := 0
+	delaunay.IterTriangles(func(tri Triangle) {
+		n++
+	})
+	assert.Equal(t, len(mesh.Triangles), n)
+
+	// Euler's formula for a triangulated disk
+	assert.Equal(t, len(mesh.Vertices)-len(mesh.Edges)+len(mesh.Triangles), 1)
+
+	for _, tri := range mesh.Triangles {
+		assert.True(t, orient(mesh.Vertices[tri[0]], mesh.Vertices[tri[1]], mesh.Vertices[tri[2]]) > 0)
+	}
+	assert.Equal(t, mesh.Index(Point{-5, -5}), -1)
+}
+
+func TestPowerDiagram(t *testing.T) {
+	w, h := 200, 150
+	n := 60
+	maxWeight := MaxPowerWeight(n, w, h)
+	rng := rand.New(rand.NewSource(3))
+
+	diagram := NewPowerDiagram(w, h, maxWeight)
+	for i := 0; i < n; i++ {
+		diagram.Update(i, Point{int32(rng.Intn(w)), int32(rng.Intn(h))}, rng.Float64()*maxWeight)
+	}
+	checkPowerDiagram(t, diagram, w, h)
+
+	// Incremental updates match a power diagram calculated from scratch
+	for i := 0; i < 200; i++ {
+		diagram.Update(rng.Intn(n), Point{int32(rng.Intn(w)), int32(rng.Intn(h))}, rng.Float64()*maxWeight)
+	}
+	checkPowerDiagram(t, diagram, w, h)
+
+	other := NewPowerDiagram(w, h, maxWeight)
+	for i := 0; i < n; i++ {
+		p, weight := diagram.Site(i)
+		other.Update(i, p, weight)
+	}
+	for i := 0; i < n; i++ {
+		assert.InDelta(t, polygonArea(diagram.Cell(i)), polygonArea(other.Cell(i)), 1e-6)
+	}
+
+	// A site with a much larger weight takes over the cell of a nearby site
+	diagram = NewPowerDiagram(w, h, maxWeight)
+	diagram.Update(0, Point{100, 75}, maxWeight)
+	diagram.Update(1, Point{101, 75}, 0)
+	diagram.Update(2, Point{20, 20}, 0)
+	assert.Empty(t, diagram.Cell(1))
+	checkPowerDiagram(t, diagram, w, h)
+
+	// And gives it back when its weight decreases
+	diagram.Update(0, Point{100, 75}, 0)
+	assert.NotEmpty(t, diagram.Cell(1))
+	checkPowerDiagram(t, diagram, w, h)
+}
+
+// checkPowerDiagram checks that the cells of a power diagram cover the whole area, and that the centroid of
+// each cell is closest to its own site by the power distance.
+func checkPowerDiagram(t *testing.T, d *PowerDiagram, w, h int) {
+	total := 0.
+
+	for i := 0; i < d.NumSites(); i++ {
+		cell := d.Cell(i)
+		area := polygonArea(cell)
+		total += area
+
+		if area < 1 {
+			continue
+		}
+
+		var cX, cY float64
+		for _, p := range cell {
+			cX += p.X / float64(len(cell))
+			cY += p.Y / float64(len(cell))
+		}
+
+		power := func(j int) float64 {
+			p, weight := d.Site(j)
+			dX, dY := cX-float64(p.X), cY-float64(p.Y)
+			return dX*dX + dY*dY - weight
+		}
+
+		for j := 0; j < d.NumSites(); j++ {
+			assert.LessOrEqual(t, power(i), power(j)+1e-6)
+		}
+	}
+
+	assert.InDelta(t, total, float64(w*h), 1e-6)
+}
+
+func polygonArea(polygon []FloatPoint) float64 {
+	area := 0.
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return math.Abs(area) / 2
+}
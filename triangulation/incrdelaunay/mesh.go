@@ -0,0 +1,91 @@
+package incrdelaunay
+
+import "sort"
+
+// Mesh is an indexed representation of the triangles of a triangulation, which includes the topology
+// of the triangles as well as their coordinates.
+type Mesh struct {
+	// The unique vertices of the triangles, sorted by their X and then Y coordinates.
+	Vertices []Point
+
+	// The vertices of each triangle as indexes of Vertices, in counter-clockwise order
+	// (when the Y axis points up).
+	Triangles [][3]int
+
+	// The unique edges of the triangles as indexes of Vertices, with the lower index first.
+	Edges [][2]int
+
+	// Neighbors[i][j] is the index of the triangle sharing the edge opposite of vertex j of triangle i,
+	// or -1 if the edge is on the boundary of the mesh.
+	Neighbors [][3]int
+}
+
+// Mesh returns the triangles of the triangulation as a Mesh.
+// Like IterTriangles, triangles connected to the super triangle aren't included.
+func (d Delaunay) Mesh() Mesh {
+	var mesh Mesh
+
+	d.IterTriangles(func(t Triangle) {
+		mesh.Vertices = append(mesh.Vertices, t.A, t.B, t.C)
+	})
+
+	// Sort and remove duplicate vertices
+	sort.Slice(mesh.Vertices, func(i, j int) bool {
+		return lessPoint(mesh.Vertices[i], mesh.Vertices[j])
+	})
+	mesh.Vertices = dedupe(mesh.Vertices)
+
+	// For finding the triangles which share each edge
+	type edgeSide struct {
+		triangle, vertex int
+	}
+	edges := map[[2]int]edgeSide{}
+
+	d.IterTriangles(func(t Triangle) {
+		a, b, c := mesh.Index(t.A), mesh.Index(t.B), mesh.Index(t.C)
+		if orient(t.A, t.B, t.C) < 0 {
+			b, c = c, b
+		}
+
+		i := len(mesh.Triangles)
+		mesh.Triangles = append(mesh.Triangles, [3]int{a, b, c})
+		mesh.Neighbors = append(mesh.Neighbors, [3]int{-1, -1, -1})
+
+		tri := mesh.Triangles[i]
+		for j := 0; j < 3; j++ {
+			// The edge opposite of vertex j
+			edge := [2]int{tri[(j+1)%3], tri[(j+2)%3]}
+			if edge[0] > edge[1] {
+				edge[0], edge[1] = edge[1], edge[0]
+			}
+
+			if other, ok := edges[edge]; ok {
+				mesh.Neighbors[i][j] = other.triangle
+				mesh.Neighbors[other.triangle][other.vertex] = i
+			} else {
+				edges[edge] = edgeSide{triangle: i, vertex: j}
+				mesh.Edges = append(mesh.Edges, edge)
+			}
+		}
+	})
+
+	return mesh
+}
+
+// Index returns the index of a vertex in Vertices, or -1 if the point isn't a vertex of the mesh.
+func (m Mesh) Index(p Point) int {
+	i := sort.Search(len(m.Vertices), func(i int) bool {
+		return !lessPoint(m.Vertices[i], p)
+	})
+
+	if i < len(m.Vertices) && m.Vertices[i] == p {
+		return i
+	}
+
+	return -1
+}
+
+// lessPoint returns if point a is before point b when sorted by the X and then Y coordinates.
+func lessPoint(a, b Point) bool {
+	return a.X < b.X || (a.X == b.X && a.Y < b.Y)
+}
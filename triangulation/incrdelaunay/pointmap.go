@@ -14,7 +14,7 @@ func newPointMap(size int) pointMap {
 
 // AddPoint adds a point to the pointMap, accounting for duplicates.
 func (pm *pointMap) AddPoint(point Point) uint16 {
-	x, y := uint16(point.X), uint16(point.Y)
+	x, y := point.X, point.Y
 	index := point.Hash() % len(pm.points)
 
 	// Check if the point already exists
@@ -37,7 +37,7 @@ func (pm *pointMap) AddPoint(point Point) uint16 {
 // RemovePoint removes a point from the pointMap, accounting for duplicates,
 // and returning the number of the same point left.
 func (pm *pointMap) RemovePoint(point Point) uint16 {
-	x, y := uint16(point.X), uint16(point.Y)
+	x, y := point.X, point.Y
 	index := point.Hash() % len(pm.points)
 
 	for i, p := range pm.points[index] {
@@ -59,7 +59,7 @@ func (pm *pointMap) RemovePoint(point Point) uint16 {
 }
 
 func (pm *pointMap) CopiesOf(point Point) uint16 {
-	x, y := uint16(point.X), uint16(point.Y)
+	x, y := point.X, point.Y
 	index := point.Hash() % len(pm.points)
 
 	for _, p := range pm.points[index] {
@@ -99,8 +99,8 @@ func (pm pointMap) IterPoints(point func(Point)) {
 	for i := range pm.points {
 		for _, p := range pm.points[i] {
 			point(Point{
-				X: int16(p.x),
-				Y: int16(p.y),
+				X: p.x,
+				Y: p.y,
 			})
 		}
 	}
@@ -108,6 +108,6 @@ func (pm pointMap) IterPoints(point func(Point)) {
 
 // pointEntry is used to keep track of a point and how many copies of a point there are.
 type pointEntry struct {
-	x, y  uint16
+	x, y  int32
 	count uint16 // the number of a point the hash table contains.
 }
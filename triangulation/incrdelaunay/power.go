@@ -0,0 +1,359 @@
+package incrdelaunay
+
+import "math"
+
+// powerGridSize is the number of columns and rows of the grid used to find the sites near a cell.
+const powerGridSize = 16
+
+// MaxPowerWeight returns the largest weight a site of a PowerDiagram with a number of sites and a size should
+// have. It's the average area of a cell, so a site with the maximum weight can grow a cell around twice as wide
+// as an ordinary Voronoi cell.
+func MaxPowerWeight(sites, w, h int) float64 {
+	if sites < 1 {
+		sites = 1
+	}
+	return float64(w*h) / float64(sites)
+}
+
+// PowerDiagram is a power diagram (also known as a Laguerre-Voronoi diagram), which is a weighted Voronoi
+// diagram where the cell of each site contains the points closest to it when the distance is measured by the
+// power distance |x - site|² - weight. Sites with a larger weight have larger cells, and sites with a small enough
+// weight can have no cell at all.
+//
+// Sites are identified by their index, and changing a site only recalculates the cells around it.
+//
+// It's separate from IVoronoi, whose cells are built from the circumcenters of a Delaunay triangulation and whose
+// sites are identified by their position. A power diagram is instead the dual of a weighted (regular)
+// triangulation, where sites can be hidden and have no cell, and a site whose weight changes keeps its position,
+// neither of which Delaunay supports. The cells are calculated directly by clipping instead.
+type PowerDiagram struct {
+	w, h      int
+	maxWeight float64 // The largest weight of any site, which limits how far away sites can affect a cell.
+
+	sites []powerSite
+
+	// The cells of the sites, which are never modified after being created so they can be shared.
+	cells [][]FloatPoint
+	// The sites which share an edge with the cell of each site.
+	neighbors [][]int32
+
+	hidden []int32 // The sites without a cell.
+
+	grid                [][]int32 // The indexes of the sites in each box of the grid, by row.
+	boxWidth, boxHeight float64
+
+	update  []int32       // For performance purposes.
+	polygon []powerVertex // For performance purposes.
+	clipped []powerVertex // For performance purposes.
+}
+
+// powerSite is a site of a PowerDiagram.
+type powerSite struct {
+	point  Point
+	weight float64
+}
+
+// powerVertex is a vertex of a cell being calculated, as well as the site which creates the edge starting at the
+// vertex (or -1 for the edges of the image).
+type powerVertex struct {
+	p    FloatPoint
+	edge int32
+}
+
+// NewPowerDiagram returns an empty PowerDiagram with cells clipped to (0, 0) to (w, h).
+// The weights of the sites must be between 0 and maxWeight (see MaxPowerWeight).
+func NewPowerDiagram(w, h int, maxWeight float64) *PowerDiagram {
+	return &PowerDiagram{
+		w:         w,
+		h:         h,
+		maxWeight: maxWeight,
+		grid:      make([][]int32, powerGridSize*powerGridSize),
+		boxWidth:  float64(w) / powerGridSize,
+		boxHeight: float64(h) / powerGridSize,
+	}
+}
+
+// Update sets the point and weight of the site at index i, adding the site if i is the number of sites.
+// The cells of the sites around both the old and new point are recalculated.
+func (d *PowerDiagram) Update(i int, p Point, weight float64) {
+	weight = math.Max(0, math.Min(weight, d.maxWeight))
+
+	d.update = d.update[:0]
+
+	if i == len(d.sites) {
+		d.sites = append(d.sites, powerSite{point: p, weight: weight})
+		d.cells = append(d.cells, nil)
+		d.neighbors = append(d.neighbors, nil)
+	} else {
+		old := d.sites[i]
+		if old.point == p && old.weight == weight {
+			return
+		}
+
+		// The neighbors of the old cell, and any hidden sites which the old site may have been hiding
+		d.update = append(d.update, d.neighbors[i]...)
+		d.update = append(d.update, d.hidden...)
+		d.removeFromGrid(int32(i))
+
+		d.sites[i] = powerSite{point: p, weight: weight}
+	}
+
+	d.addToGrid(int32(i))
+	d.calculateCell(int32(i))
+
+	// The neighbors of the new cell
+	d.update = append(d.update, d.neighbors[i]...)
+
+	// Sites whose cells change can change the cells next to them, such as cells which are completely covered
+	// by the new cell and don't share an edge with it, so the update spreads until the cells stay the same
+	for n := 0; n < len(d.update); n++ {
+		j := d.update[n]
+		if j == int32(i) || containsIndex32(d.update[:n], j) {
+			continue
+		}
+
+		oldCell, oldNeighbors := d.cells[j], d.neighbors[j]
+		d.calculateCell(j)
+
+		if !equalCells(oldCell, d.cells[j]) {
+			d.update = append(d.update, oldNeighbors...)
+			d.update = append(d.update, d.neighbors[j]...)
+		}
+	}
+}
+
+// Site returns the point and weight of the site at index i.
+func (d *PowerDiagram) Site(i int) (Point, float64) {
+	return d.sites[i].point, d.sites[i].weight
+}
+
+// NumSites returns the number of sites.
+func (d *PowerDiagram) NumSites() int {
+	return len(d.sites)
+}
+
+// Cell returns the vertices of the cell of the site at index i, which is empty if the site has no cell.
+// The slice must not be modified.
+func (d *PowerDiagram) Cell(i int) []FloatPoint {
+	return d.cells[i]
+}
+
+// IterPolygons calls function polygon with the vertices of each non-empty cell.
+func (d *PowerDiagram) IterPolygons(polygon func([]FloatPoint)) {
+	for _, c := range d.cells {
+		if len(c) > 0 {
+			polygon(c)
+		}
+	}
+}
+
+// Set sets the power diagram to another power diagram with the same size.
+func (d *PowerDiagram) Set(other *PowerDiagram) {
+	d.maxWeight = other.maxWeight
+	d.sites = append(d.sites[:0], other.sites...)
+	d.cells = append(d.cells[:0], other.cells...)
+	d.neighbors = append(d.neighbors[:0], other.neighbors...)
+	d.hidden = append(d.hidden[:0], other.hidden...)
+
+	for i := range d.grid {
+		d.grid[i] = append(d.grid[i][:0], other.grid[i]...)
+	}
+}
+
+// box returns the index of the box of the grid a point is in.
+func (d *PowerDiagram) box(p Point) (int, int) {
+	x := int(float64(p.X) / d.boxWidth)
+	y := int(float64(p.Y) / d.boxHeight)
+
+	if x < 0 {
+		x = 0
+	} else if x >= powerGridSize {
+		x = powerGridSize - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= powerGridSize {
+		y = powerGridSize - 1
+	}
+
+	return x, y
+}
+
+func (d *PowerDiagram) addToGrid(i int32) {
+	x, y := d.box(d.sites[i].point)
+	box := &d.grid[y*powerGridSize+x]
+	*box = append(*box, i)
+}
+
+func (d *PowerDiagram) removeFromGrid(i int32) {
+	x, y := d.box(d.sites[i].point)
+	box := &d.grid[y*powerGridSize+x]
+
+	for n, j := range *box {
+		if j == i {
+			(*box)[n] = (*box)[len(*box)-1]
+			*box = (*box)[:len(*box)-1]
+			return
+		}
+	}
+}
+
+// calculateCell calculates the cell of a site by clipping the image with the half-planes of the sites around it,
+// in rings of boxes of the grid until no further sites can affect the cell.
+func (d *PowerDiagram) calculateCell(i int32) {
+	site := d.sites[i]
+	w, h := float64(d.w), float64(d.h)
+
+	d.polygon = append(d.polygon[:0],
+		powerVertex{FloatPoint{0, 0}, -1},
+		powerVertex{FloatPoint{w, 0}, -1},
+		powerVertex{FloatPoint{w, h}, -1},
+		powerVertex{FloatPoint{0, h}, -1},
+	)
+
+	bX, bY := d.box(site.point)
+	boxSize := math.Min(d.boxWidth, d.boxHeight)
+
+	for r := 0; r < powerGridSize && len(d.polygon) > 0; r++ {
+		// Sites in this ring are at least this far away
+		if minDist := float64(r-1) * boxSize; minDist > 0 {
+			// The furthest distance of the cell from the site
+			radius := 0.
+			for _, v := range d.polygon {
+				dX, dY := v.p.X-float64(site.point.X), v.p.Y-float64(site.point.Y)
+				radius = math.Max(radius, dX*dX+dY*dY)
+			}
+			radius = math.Sqrt(radius)
+
+			// The edge between two sites at a distance of dist is at least (dist² + weight - maxWeight) / (2 dist)
+			// away from the site, so sites further than this can't affect the cell
+			if minDist >= radius+math.Sqrt(radius*radius+d.maxWeight-site.weight) {
+				break
+			}
+		}
+
+		for y := bY - r; y <= bY+r; y++ {
+			if y < 0 || y >= powerGridSize {
+				continue
+			}
+			for x := bX - r; x <= bX+r; x++ {
+				if x < 0 || x >= powerGridSize {
+					continue
+				}
+				// Only the boxes on the edge of the ring
+				if y != bY-r && y != bY+r && x != bX-r && x != bX+r {
+					continue
+				}
+
+				for _, j := range d.grid[y*powerGridSize+x] {
+					if j != i {
+						d.clip(i, j)
+					}
+				}
+			}
+		}
+	}
+
+	// Store the cell and the sites which created its edges
+	var cell []FloatPoint
+	var neighbors []int32
+
+	for _, v := range d.polygon {
+		cell = append(cell, v.p)
+		if v.edge != -1 && !containsIndex32(neighbors, v.edge) {
+			neighbors = append(neighbors, v.edge)
+		}
+	}
+
+	d.cells[i] = cell
+	d.neighbors[i] = neighbors
+
+	hidden := indexOf32(d.hidden, i)
+	if len(cell) == 0 && hidden == -1 {
+		d.hidden = append(d.hidden, i)
+	} else if len(cell) > 0 && hidden != -1 {
+		d.hidden[hidden] = d.hidden[len(d.hidden)-1]
+		d.hidden = d.hidden[:len(d.hidden)-1]
+	}
+}
+
+// clip clips the cell being calculated for a site to the points with a lower power distance to the site than
+// to site j, using the Sutherland-Hodgman algorithm.
+func (d *PowerDiagram) clip(i, j int32) {
+	site, other := d.sites[i], d.sites[j]
+
+	// The points x in the cell satisfy a · x <= b
+	pX, pY := float64(site.point.X), float64(site.point.Y)
+	oX, oY := float64(other.point.X), float64(other.point.Y)
+
+	aX, aY := 2*(oX-pX), 2*(oY-pY)
+	b := oX*oX + oY*oY - pX*pX - pY*pY + site.weight - other.weight
+
+	if aX == 0 && aY == 0 {
+		// Sites at the same point: the one with the larger weight (or the lower index) takes the whole cell
+		if b < 0 || (b == 0 && j < i) {
+			d.polygon = d.polygon[:0]
+		}
+		return
+	}
+
+	d.clipped = d.clipped[:0]
+
+	for k, v := range d.polygon {
+		next := d.polygon[(k+1)%len(d.polygon)]
+
+		vIn := aX*v.p.X+aY*v.p.Y <= b
+		nextIn := aX*next.p.X+aY*next.p.Y <= b
+
+		if vIn {
+			d.clipped = append(d.clipped, v)
+		}
+
+		if vIn != nextIn {
+			// The intersection of the edge with the line a · x = b
+			dV := aX*v.p.X + aY*v.p.Y - b
+			dNext := aX*next.p.X + aY*next.p.Y - b
+			t := dV / (dV - dNext)
+
+			p := FloatPoint{
+				X: v.p.X + (next.p.X-v.p.X)*t,
+				Y: v.p.Y + (next.p.Y-v.p.Y)*t,
+			}
+
+			if vIn {
+				// Leaving the half-plane, so the edge from the intersection is along the line
+				d.clipped = append(d.clipped, powerVertex{p, j})
+			} else {
+				d.clipped = append(d.clipped, powerVertex{p, v.edge})
+			}
+		}
+	}
+
+	d.polygon, d.clipped = d.clipped, d.polygon
+}
+
+// equalCells returns if two cells have the same vertices.
+func equalCells(a, b []FloatPoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsIndex32(indexes []int32, i int32) bool {
+	return indexOf32(indexes, i) != -1
+}
+
+func indexOf32(indexes []int32, i int32) int {
+	for n, v := range indexes {
+		if v == i {
+			return n
+		}
+	}
+	return -1
+}
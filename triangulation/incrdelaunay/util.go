@@ -3,15 +3,27 @@ package incrdelaunay
 import "C"
 import (
 	"math"
+	"math/big"
 )
 
-// inCircle returns if point d is in the circumcircle of triangle abc.
-func inCircle(aX, aY, bX, bY, cX, cY, dX, dY int64) int64 {
+// maxExactDelta is the largest coordinate difference for which inCircle can be calculated
+// using int64 arithmetic without overflowing.
+const maxExactDelta = 29000
+
+// inCircle returns a positive value if point d is in the circumcircle of triangle abc,
+// zero if it's on the circumcircle and a negative value if it's outside.
+func inCircle(aX, aY, bX, bY, cX, cY, dX, dY int64) float64 {
 	if orientation(aX, aY, bX, bY, cX, cY) < 0 {
 		aX, bX = bX, aX
 		aY, bY = bY, aY
 	}
 
+	if !withinExactDelta(aX-dX, aY-dY, bX-dX, bY-dY, cX-dX, cY-dY) {
+		// Large triangles (typically ones connected to the super triangle of a large image)
+		// would overflow, so the determinant is calculated exactly with arbitrary precision
+		return inCircleBig(aX-dX, aY-dY, bX-dX, bY-dY, cX-dX, cY-dY)
+	}
+
 	a11 := aX - dX
 	a21 := bX - dX
 	a31 := cX - dX
@@ -20,9 +32,46 @@ func inCircle(aX, aY, bX, bY, cX, cY, dX, dY int64) int64 {
 	a22 := bY - dY
 	a32 := cY - dY
 
-	return (a11*a11+a12*a12)*(a21*a32-a31*a22) +
+	return float64((a11*a11+a12*a12)*(a21*a32-a31*a22) +
 		(a21*a21+a22*a22)*(a31*a12-a11*a32) +
-		(a31*a31+a32*a32)*(a11*a22-a21*a12)
+		(a31*a31+a32*a32)*(a11*a22-a21*a12))
+}
+
+// withinExactDelta returns if all the coordinate differences are small enough for inCircle to use int64 arithmetic.
+func withinExactDelta(deltas ...int64) bool {
+	for _, d := range deltas {
+		if d > maxExactDelta || d < -maxExactDelta {
+			return false
+		}
+	}
+	return true
+}
+
+// inCircleBig calculates the same determinant as inCircle using math/big, given the coordinates
+// of triangle abc relative to point d.
+func inCircleBig(a11, a12, a21, a22, a31, a32 int64) float64 {
+	lift := func(x, y int64) *big.Int {
+		bX, bY := big.NewInt(x), big.NewInt(y)
+		return bX.Add(bX.Mul(bX, bX), bY.Mul(bY, bY))
+	}
+	cross := func(x0, y0, x1, y1 int64) *big.Int {
+		l, r := big.NewInt(x0), big.NewInt(x1)
+		l.Mul(l, big.NewInt(y1))
+		r.Mul(r, big.NewInt(y0))
+		return l.Sub(l, r)
+	}
+
+	det := lift(a11, a12)
+	det.Mul(det, cross(a21, a22, a31, a32))
+
+	term := lift(a21, a22)
+	det.Add(det, term.Mul(term, cross(a31, a32, a11, a12)))
+
+	term = lift(a31, a32)
+	det.Add(det, term.Mul(term, cross(a11, a12, a21, a22)))
+
+	f, _ := new(big.Float).SetInt(det).Float64()
+	return f
 }
 
 // orientation returns a positive integer if points abc are clockwise.
@@ -41,5 +90,5 @@ func calculateScore(a, b, c, d Point) float64 {
 
 	inCircle := inCircle(int64(a.X), int64(a.Y), int64(b.X), int64(b.Y), int64(c.X), int64(c.Y), int64(d.X), int64(d.Y))
 
-	return float64(inCircle) / float64(orientation)
+	return inCircle / float64(orientation)
 }
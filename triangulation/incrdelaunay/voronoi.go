@@ -5,6 +5,8 @@ import (
 	"sort"
 )
 
+// NewVoronoi returns a new incremental Voronoi diagram.
+// Like NewDelaunay, it panics if the size isn't supported, which can be checked beforehand with CheckSize.
 func NewVoronoi(w, h int) *IVoronoi {
 	voronoi := IVoronoi{
 		delaunay:   NewDelaunay(w, h),
@@ -35,7 +37,7 @@ func (v *IVoronoi) Insert(point Point) {
 	v.pointsToUpdate = v.pointsToUpdate[:0]
 	triangles := v.delaunay.triangles
 
-	v.delaunay.grid.IterCircumcirclesThatContain(point, triangles, func(i uint16) {
+	v.delaunay.grid.IterCircumcirclesThatContain(point, triangles, func(i uint32) {
 		t := triangles[i]
 		v.addPointToUpdate(t.A)
 		v.addPointToUpdate(t.B)
@@ -65,7 +67,7 @@ func (v *IVoronoi) Remove(point Point) {
 	v.pointsToUpdate = v.pointsToUpdate[:0]
 	triangles := v.delaunay.triangles
 
-	v.delaunay.grid.IterThatHasVertex(point, triangles, func(i uint16) {
+	v.delaunay.grid.IterThatHasVertex(point, triangles, func(i uint32) {
 		t := triangles[i]
 
 		v.addPointToUpdate(t.A)
@@ -102,7 +104,7 @@ func (v *IVoronoi) processPoint(point Point) {
 	v.points = v.points[:0]
 	clip := false
 
-	v.delaunay.grid.IterThatHasVertex(point, triangles, func(i uint16) {
+	v.delaunay.grid.IterThatHasVertex(point, triangles, func(i uint32) {
 		circ := triangles[i].Circumcircle
 
 		new := FloatPoint{
@@ -308,7 +310,17 @@ var edges = [4][2]FloatPoint{
 	{FloatPoint{0, 1}, FloatPoint{0, 0}},
 }
 
+// Voronoi calculates the Voronoi cells of the points of a triangulation clipped to (0, 0) to (w, h),
+// calling function polygon with the vertices of each cell.
 func Voronoi(delaunay *Delaunay, polygon func([]FloatPoint), w, h int) {
+	VoronoiCells(delaunay, func(site Point, cell []FloatPoint) {
+		polygon(cell)
+	}, w, h)
+}
+
+// VoronoiCells is like Voronoi, except function cell is also given the point (site) each cell belongs to.
+// Duplicate points only have one cell.
+func VoronoiCells(delaunay *Delaunay, cell func(site Point, polygon []FloatPoint), w, h int) {
 	triangles := delaunay.triangles
 	points := make([]FloatPoint, 0, 8)
 
@@ -318,7 +330,7 @@ func Voronoi(delaunay *Delaunay, polygon func([]FloatPoint), w, h int) {
 		points = points[:0]
 		clip := false
 
-		delaunay.grid.IterThatHasVertex(point, triangles, func(i uint16) {
+		delaunay.grid.IterThatHasVertex(point, triangles, func(i uint32) {
 			circ := triangles[i].Circumcircle
 
 			new := FloatPoint{
@@ -479,11 +491,11 @@ func Voronoi(delaunay *Delaunay, polygon func([]FloatPoint), w, h int) {
 			})
 
 			if len(newPolygon) != 0 {
-				polygon(newPolygon)
+				cell(point, newPolygon)
 			}
 		} else {
 			if len(points) != 0 {
-				polygon(points)
+				cell(point, points)
 			}
 		}
 	})
@@ -0,0 +1,35 @@
+package triangulation
+
+import (
+	"github.com/RH12503/Triangula/normgeom"
+	"github.com/RH12503/Triangula/triangulation/incrdelaunay"
+	"math"
+)
+
+// Mesh creates a Delaunay triangulation from a group of points and returns it as an indexed mesh, using
+// the same coordinates as the fitness functions.
+// The index of the mesh vertex of each point is also returned, so data can be mapped between the point group
+// and the mesh. Duplicate points have the same vertex.
+// It panics if w or h is larger than incrdelaunay.MaxSize.
+func Mesh(points normgeom.NormPointGroup, w, h int) (incrdelaunay.Mesh, []int) {
+	triangulation := incrdelaunay.NewDelaunay(w, h)
+
+	vertices := make([]incrdelaunay.Point, len(points))
+
+	for i, p := range points {
+		vertices[i] = incrdelaunay.Point{
+			X: int32(math.Round(p.X * float64(w))),
+			Y: int32(math.Round(p.Y * float64(h))),
+		}
+		triangulation.Insert(vertices[i])
+	}
+
+	mesh := triangulation.Mesh()
+
+	indexes := make([]int, len(points))
+	for i, v := range vertices {
+		indexes[i] = mesh.Index(v)
+	}
+
+	return mesh, indexes
+}
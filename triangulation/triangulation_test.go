@@ -18,3 +18,38 @@ func TestTriangulate(t *testing.T) {
 
 	assert.Equal(t, tri, []geom.Triangle{geom.NewTriangle(4, 5, 2, 4, 0, 10)})
 }
+
+func TestMesh(t *testing.T) {
+	points := normgeom.NormPointGroup{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: 0.5, Y: 0.4}, {X: 1, Y: 1}}
+	mesh, indexes := Mesh(points, 10, 10)
+
+	assert.Equal(t, len(mesh.Vertices), 5)
+	assert.Equal(t, len(mesh.Triangles), 4)
+	assert.Equal(t, len(mesh.Edges), 8)
+
+	// Each point should map to its vertex, including duplicates
+	assert.Equal(t, indexes[2], indexes[5])
+	for i, v := range indexes {
+		assert.Equal(t, mesh.Vertices[v].X, int32(points[i].X*10+0.5))
+		assert.Equal(t, mesh.Vertices[v].Y, int32(points[i].Y*10+0.5))
+	}
+
+	// The center is shared by all the triangles, each of which borders two others
+	for i, tri := range mesh.Triangles {
+		center := -1
+		for j, v := range tri {
+			if v == indexes[4] {
+				center = j
+			}
+		}
+		assert.NotEqual(t, center, -1)
+		assert.Equal(t, mesh.Neighbors[i][center], -1)
+
+		for j, n := range mesh.Neighbors[i] {
+			if j == center {
+				continue
+			}
+			assert.Contains(t, mesh.Neighbors[n], i)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"github.com/RH12503/Triangula/algorithm"
 	"github.com/RH12503/Triangula/algorithm/evaluator"
+	"github.com/RH12503/Triangula/density"
 	"github.com/RH12503/Triangula/fitness"
 	"github.com/RH12503/Triangula/generator"
 	imageData "github.com/RH12503/Triangula/image"
@@ -20,7 +21,7 @@ func DefaultAlgorithm(numPoints int, image image.Image) algorithm.Algorithm {
 	}
 
 	evaluatorFactory := func(n int) evaluator.Evaluator {
-		return evaluator.NewParallel(fitness.TrianglesImageFunctions(img, 5, n), 22)
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(img, n), evaluator.DefaultCacheSize)
 	}
 
 	var mutator mutation.Method
@@ -30,3 +31,70 @@ func DefaultAlgorithm(numPoints int, image image.Image) algorithm.Algorithm {
 	algo := algorithm.NewSimple(pointFactory, 400, 5, evaluatorFactory, mutator)
 	return algo
 }
+
+// BorderAlgorithm returns an algorithm like DefaultAlgorithm, except the corners of the image are pinned and
+// a number of the points can only slide along its edges, so the triangles always cover the whole image.
+func BorderAlgorithm(numPoints, edgePoints int, image image.Image) algorithm.Algorithm {
+	img := imageData.ToData(image)
+
+	gen := generator.NewBorderGenerator(generator.RandomGenerator{}, edgePoints)
+
+	pointFactory := func() normgeom.NormPointGroup {
+		return gen.Generate(numPoints)
+	}
+
+	evaluatorFactory := func(n int) evaluator.Evaluator {
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(img, n), evaluator.DefaultCacheSize)
+	}
+
+	mutator := mutation.NewBorderMethod(mutation.DefaultGaussianMethod(numPoints-4), gen.Border())
+
+	algo := algorithm.NewSimple(pointFactory, 400, 5, evaluatorFactory, mutator)
+	algo.SetPins(gen.Pins())
+	return algo
+}
+
+// PowerAlgorithm returns an algorithm which creates the polygons of a power diagram with a number of sites,
+// where each site has a weight which is optimized alongside its position. The point groups of the algorithm
+// have two points for each site, and are turned into polygons using polygonation.PowerPolygonate.
+func PowerAlgorithm(numSites int, image image.Image) algorithm.Algorithm {
+	img := imageData.ToData(image)
+
+	gen := generator.NewPowerGenerator(generator.RandomGenerator{})
+
+	pointFactory := func() normgeom.NormPointGroup {
+		return gen.Generate(numSites * 2)
+	}
+
+	evaluatorFactory := func(n int) evaluator.Evaluator {
+		return evaluator.NewParallelSize(fitness.PowerImageFunctions(img, n), evaluator.DefaultCacheSize)
+	}
+
+	mutator := mutation.NewPowerMethod(mutation.DefaultGaussianMethod(numSites * 2))
+
+	algo := algorithm.NewSimple(pointFactory, 400, 5, evaluatorFactory, mutator)
+	return algo
+}
+
+// DensityAlgorithm returns an algorithm like DefaultAlgorithm, except the points are concentrated where a
+// grayscale density image painted by an artist is brighter: they're placed according to the density, and take
+// smaller steps when mutated in denser areas. Budgets optionally give areas of the image a number of points.
+func DensityAlgorithm(numPoints int, image, densityImage image.Image, budgets ...density.Budget) algorithm.Algorithm {
+	img := imageData.ToData(image)
+	m := density.FromImage(imageData.ToData(densityImage), 0.05)
+
+	gen := generator.NewDensityGenerator(m, budgets...)
+
+	pointFactory := func() normgeom.NormPointGroup {
+		return gen.Generate(numPoints)
+	}
+
+	evaluatorFactory := func(n int) evaluator.Evaluator {
+		return evaluator.NewParallelSize(fitness.TrianglesImageFunctions(img, n), evaluator.DefaultCacheSize)
+	}
+
+	mutator := mutation.NewDensityMethod(mutation.DefaultGaussianMethod(numPoints), m, budgets...)
+
+	algo := algorithm.NewSimple(pointFactory, 400, 5, evaluatorFactory, mutator)
+	return algo
+}
@@ -2,17 +2,60 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/RH12503/Triangula/algorithm"
+	"github.com/RH12503/Triangula/document"
+	"github.com/RH12503/Triangula/normgeom"
 	"io/ioutil"
 	"log"
 	"os"
 	"time"
 )
 
-// GenerateAlgorithmOutput runs an algorithm.Algorithm and writes the best point group to a file.
+// Output stores the best point group of an algorithm, and the indexes of the points which are pinned.
+type Output struct {
+	Points normgeom.NormPointGroup
+	Pinned []int
+}
+
+// algorithmOutput returns the data written by GenerateAlgorithmOutput. To stay compatible with existing
+// readers, only the point group is written unless some points are pinned.
+func algorithmOutput(algo algorithm.Algorithm) interface{} {
+	if p, ok := algo.(algorithm.PinnedAlgorithm); ok && p.Pins().Count() > 0 {
+		return Output{
+			Points: algo.Best(),
+			Pinned: p.Pins().Indexes(),
+		}
+	}
+	return algo.Best()
+}
+
+// GenerateAlgorithmOutput runs an algorithm.Algorithm and writes the best point group (and its pinned points) to a file.
 func GenerateAlgorithmOutput(outputFile string, algo algorithm.Algorithm, reps int) {
+	generateOutput(outputFile, algo, reps, func() ([]byte, error) {
+		return json.Marshal(algorithmOutput(algo))
+	})
+}
+
+// GenerateDocumentOutput runs an algorithm.Algorithm like GenerateAlgorithmOutput, except a document.Document is
+// written to the file. The points, pinned points and stats of the document are updated from the algorithm, and
+// its other fields should be set by the caller (with its seed applied using document.SeedRandom before the
+// algorithm was created).
+func GenerateDocumentOutput(outputFile string, algo algorithm.Algorithm, reps int, doc document.Document) {
+	generateOutput(outputFile, algo, reps, func() ([]byte, error) {
+		current := document.FromAlgorithm(algo, doc.Mode)
+		doc.Points, doc.Pinned, doc.Stats = current.Points, current.Pinned, current.Stats
+
+		var b bytes.Buffer
+		err := document.Write(&b, doc)
+		return b.Bytes(), err
+	})
+}
+
+// generateOutput runs an algorithm.Algorithm, writing its output to a file every number of repetitions.
+func generateOutput(outputFile string, algo algorithm.Algorithm, reps int, output func() ([]byte, error)) {
 	dataFile, _ := os.Create(outputFile + "-stats")
 	writer := bufio.NewWriter(dataFile)
 
@@ -25,7 +68,7 @@ func GenerateAlgorithmOutput(outputFile string, algo algorithm.Algorithm, reps i
 		fmt.Printf("Gen: %v | Fit: %v | Time: %v\n", stats.Generation, stats.BestFitness, float64(time.Since(ti).Microseconds())/(float64(reps)*1000.))
 		printMemUsage()
 
-		jsonOut, err := json.Marshal(algo.Best())
+		jsonOut, err := output()
 		if err != nil {
 			log.Fatal(err)
 		}